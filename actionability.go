@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// actionabilityHandler serves a page of elements in every actionability
+// state: covered, animating, zero sized, hidden, inert, disabled, readonly,
+// detached and moving away when hovered. Elements which start out non
+// actionable become actionable after "delay" (one second by default), so
+// that tests can check both auto-waiting and timeouts. Every click and input
+// is listed on the page.
+func (app *application) actionabilityHandler(w http.ResponseWriter, r *http.Request) {
+	delay, err := parseDuration(r, "delay", time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "actionability", struct{ Delay int64 }{delay.Milliseconds()})
+}
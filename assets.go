@@ -0,0 +1,20 @@
+package main
+
+import (
+	"embed"
+	"net/http"
+)
+
+// assets holds the static files served under /assets/.
+//
+// assets/web-vitals.js is the web-vitals module, pinned to the version
+// below and vendored unmodified by running go generate.
+//
+//go:generate curl -fsSL -o assets/web-vitals.js https://unpkg.com/web-vitals@3.5.2/dist/web-vitals.js
+//go:embed assets
+var assets embed.FS
+
+// assetsHandler serves the embedded static files.
+func (app *application) assetsHandler() http.Handler {
+	return http.FileServer(http.FS(assets))
+}
@@ -0,0 +1,75 @@
+// Service worker of the /sw/ app. It caches the app's assets and offline
+// page on install, serves navigations from the network with the offline
+// page as a fallback, answers /ping itself, and relays push messages to the
+// pages it controls.
+
+const CACHE = 'sw-fixture-v1';
+const PRECACHE = ['/sw/offline', '/sw/style.css'];
+
+self.addEventListener('install', (event) => {
+    event.waitUntil(
+        caches.open(CACHE)
+            .then((cache) => cache.addAll(PRECACHE))
+            .then(() => self.skipWaiting())
+    );
+});
+
+self.addEventListener('activate', (event) => {
+    event.waitUntil(
+        caches.keys()
+            .then((keys) => Promise.all(keys.filter((k) => k !== CACHE).map((k) => caches.delete(k))))
+            .then(() => self.clients.claim())
+    );
+});
+
+self.addEventListener('fetch', (event) => {
+    const url = new URL(event.request.url);
+    if (url.origin !== self.location.origin) {
+        return;
+    }
+
+    // /ping is answered by the worker, unless network=1 asks for it to be
+    // fetched from the server through the worker.
+    if (url.pathname === '/ping') {
+        if (url.searchParams.get('network') === '1') {
+            event.respondWith(fetch(event.request));
+            return;
+        }
+        event.respondWith(new Response('pong from service worker', {
+            headers: { 'Content-Type': 'text/plain', 'X-Served-By': 'service-worker' },
+        }));
+        return;
+    }
+
+    if (event.request.mode === 'navigate') {
+        event.respondWith(
+            fetch(event.request).catch(() => caches.match('/sw/offline'))
+        );
+        return;
+    }
+
+    if (PRECACHE.includes(url.pathname)) {
+        event.respondWith(
+            caches.match(event.request).then((cached) => cached || fetch(event.request))
+        );
+    }
+});
+
+async function broadcast(data) {
+    const clients = await self.clients.matchAll({ includeUncontrolled: true, type: 'window' });
+    for (const client of clients) {
+        client.postMessage({ type: 'push', data: data, time: Date.now() });
+    }
+}
+
+// Real push messages, such as the ones delivered through the DevTools
+// protocol, and push-style messages posted by pages are relayed alike.
+self.addEventListener('push', (event) => {
+    event.waitUntil(broadcast(event.data ? event.data.text() : null));
+});
+
+self.addEventListener('message', (event) => {
+    if (event.data && event.data.type === 'push') {
+        event.waitUntil(broadcast(event.data.data));
+    }
+});
@@ -0,0 +1,326 @@
+// PLACEHOLDER: this is not the upstream web-vitals module. Run go generate
+// to replace it with web-vitals@3.5.2 dist/web-vitals.js, unmodified (see
+// assets.go). Until then, values reported by the web vitals pages come from
+// this simplified stand-in, which lacks bfcache restore handling and
+// simplifies the INP estimate and prerender handling.
+
+const thresholds = {
+    CLS: [0.1, 0.25],
+    FCP: [1800, 3000],
+    FID: [100, 300],
+    INP: [200, 500],
+    LCP: [2500, 4000],
+    TTFB: [800, 1800],
+};
+
+function getNavigationEntry() {
+    return performance.getEntriesByType('navigation')[0];
+}
+
+function getActivationStart() {
+    const nav = getNavigationEntry();
+    return (nav && nav.activationStart) || 0;
+}
+
+function getNavigationType() {
+    const nav = getNavigationEntry();
+    if (document.prerendering || getActivationStart() > 0) {
+        return 'prerender';
+    }
+    if (document.wasDiscarded) {
+        return 'restore';
+    }
+    return nav ? nav.type.replace(/_/g, '-') : 'navigate';
+}
+
+function initMetric(name, value) {
+    return {
+        name: name,
+        value: value === undefined ? -1 : value,
+        rating: 'good',
+        delta: 0,
+        entries: [],
+        id: 'v3-' + Date.now() + '-' + (Math.floor(Math.random() * 8999999999999) + 1e12),
+        navigationType: getNavigationType(),
+    };
+}
+
+function getRating(name, value) {
+    const [good, poor] = thresholds[name];
+    if (value > poor) {
+        return 'poor';
+    }
+    if (value > good) {
+        return 'needs-improvement';
+    }
+    return 'good';
+}
+
+function bindReporter(callback, metric, reportAllChanges) {
+    let prevValue;
+    return (forceReport) => {
+        if (metric.value < 0) {
+            return;
+        }
+        if (forceReport || reportAllChanges) {
+            metric.delta = metric.value - (prevValue || 0);
+            if (metric.delta || prevValue === undefined) {
+                prevValue = metric.value;
+                metric.rating = getRating(metric.name, metric.value);
+                callback(metric);
+            }
+        }
+    };
+}
+
+function observe(type, callback, opts) {
+    try {
+        if (PerformanceObserver.supportedEntryTypes.includes(type)) {
+            const po = new PerformanceObserver((list) => {
+                // Delay by a microtask so that entries dispatched in the same
+                // task as a visibility change are included.
+                Promise.resolve().then(() => callback(list.getEntries()));
+            });
+            po.observe(Object.assign({ type: type, buffered: true }, opts || {}));
+            return po;
+        }
+    } catch (e) {
+        // Unsupported entry type.
+    }
+}
+
+function onHidden(callback) {
+    const handler = (event) => {
+        if (event.type === 'pagehide' || document.visibilityState === 'hidden') {
+            callback();
+        }
+    };
+    addEventListener('visibilitychange', handler, true);
+    addEventListener('pagehide', handler, true);
+}
+
+function runOnce(callback) {
+    let called = false;
+    return () => {
+        if (!called) {
+            callback();
+            called = true;
+        }
+    };
+}
+
+function whenActivated(callback) {
+    if (document.prerendering) {
+        addEventListener('prerenderingchange', () => callback(), true);
+    } else {
+        callback();
+    }
+}
+
+export function onFCP(onReport, opts) {
+    opts = opts || {};
+    whenActivated(() => {
+        const metric = initMetric('FCP');
+        let report;
+        const po = observe('paint', (entries) => {
+            entries.forEach((entry) => {
+                if (entry.name === 'first-contentful-paint') {
+                    po.disconnect();
+                    metric.value = Math.max(entry.startTime - getActivationStart(), 0);
+                    metric.entries.push(entry);
+                    report(true);
+                }
+            });
+        });
+        if (po) {
+            report = bindReporter(onReport, metric, opts.reportAllChanges);
+        }
+    });
+}
+
+export function onCLS(onReport, opts) {
+    opts = opts || {};
+    const metric = initMetric('CLS', 0);
+    let report;
+    let sessionValue = 0;
+    let sessionEntries = [];
+
+    const handleEntries = (entries) => {
+        entries.forEach((entry) => {
+            if (entry.hadRecentInput) {
+                return;
+            }
+            const first = sessionEntries[0];
+            const last = sessionEntries[sessionEntries.length - 1];
+            if (sessionValue && entry.startTime - last.startTime < 1000 && entry.startTime - first.startTime < 5000) {
+                sessionValue += entry.value;
+                sessionEntries.push(entry);
+            } else {
+                sessionValue = entry.value;
+                sessionEntries = [entry];
+            }
+        });
+        if (sessionValue > metric.value) {
+            metric.value = sessionValue;
+            metric.entries = sessionEntries;
+            report();
+        }
+    };
+
+    const po = observe('layout-shift', handleEntries);
+    if (po) {
+        report = bindReporter(onReport, metric, opts.reportAllChanges);
+        onHidden(() => {
+            handleEntries(po.takeRecords());
+            report(true);
+        });
+    }
+}
+
+export function onFID(onReport, opts) {
+    opts = opts || {};
+    whenActivated(() => {
+        const metric = initMetric('FID');
+        let report;
+        const handleEntries = (entries) => {
+            entries.forEach((entry) => {
+                metric.value = entry.processingStart - entry.startTime;
+                metric.entries.push(entry);
+                report(true);
+            });
+        };
+        const po = observe('first-input', handleEntries);
+        report = bindReporter(onReport, metric, opts.reportAllChanges);
+        if (po) {
+            onHidden(runOnce(() => {
+                handleEntries(po.takeRecords());
+                po.disconnect();
+            }));
+        }
+    });
+}
+
+export function onINP(onReport, opts) {
+    opts = opts || {};
+    whenActivated(() => {
+        const metric = initMetric('INP');
+        let report;
+
+        // The 10 longest interactions, keyed by interaction ID.
+        const longest = [];
+        const byId = {};
+        const seen = new Set();
+
+        const processEntry = (entry) => {
+            if (!entry.interactionId) {
+                return;
+            }
+            seen.add(entry.interactionId);
+            const min = longest[longest.length - 1];
+            const existing = byId[entry.interactionId];
+            if (existing || longest.length < 10 || entry.duration > min.latency) {
+                if (existing) {
+                    existing.entries.push(entry);
+                    existing.latency = Math.max(existing.latency, entry.duration);
+                } else {
+                    const interaction = { id: entry.interactionId, latency: entry.duration, entries: [entry] };
+                    byId[interaction.id] = interaction;
+                    longest.push(interaction);
+                }
+                longest.sort((a, b) => b.latency - a.latency);
+                longest.splice(10).forEach((i) => delete byId[i.id]);
+            }
+        };
+
+        const estimateP98 = () => {
+            const count = performance.interactionCount || seen.size;
+            const index = Math.min(longest.length - 1, Math.floor(count / 50));
+            return longest[index];
+        };
+
+        const handleEntries = (entries) => {
+            entries.forEach(processEntry);
+            const interaction = estimateP98();
+            if (interaction && interaction.latency !== metric.value) {
+                metric.value = interaction.latency;
+                metric.entries = interaction.entries;
+                report();
+            }
+        };
+
+        const po = observe('event', handleEntries, { durationThreshold: opts.durationThreshold || 40 });
+        report = bindReporter(onReport, metric, opts.reportAllChanges);
+        if (po) {
+            if ('PerformanceEventTiming' in window && 'interactionId' in PerformanceEventTiming.prototype) {
+                po.observe({ type: 'first-input', buffered: true });
+            }
+            onHidden(() => {
+                handleEntries(po.takeRecords());
+                if (metric.value < 0 && seen.size === 0) {
+                    metric.value = 0;
+                    metric.entries = [];
+                }
+                report(true);
+            });
+        }
+    });
+}
+
+export function onLCP(onReport, opts) {
+    opts = opts || {};
+    whenActivated(() => {
+        const metric = initMetric('LCP');
+        let report;
+        const handleEntries = (entries) => {
+            const last = entries[entries.length - 1];
+            if (last && last.startTime < performance.now()) {
+                metric.value = Math.max(last.startTime - getActivationStart(), 0);
+                metric.entries = [last];
+                report();
+            }
+        };
+        const po = observe('largest-contentful-paint', handleEntries);
+        if (po) {
+            report = bindReporter(onReport, metric, opts.reportAllChanges);
+            const stopListening = runOnce(() => {
+                handleEntries(po.takeRecords());
+                po.disconnect();
+                report(true);
+            });
+            // LCP stops being observed once the user interacts with the page.
+            ['keydown', 'click'].forEach((type) => {
+                addEventListener(type, () => setTimeout(stopListening, 0), true);
+            });
+            onHidden(stopListening);
+        }
+    });
+}
+
+function whenReady(callback) {
+    if (document.prerendering) {
+        whenActivated(() => whenReady(callback));
+    } else if (document.readyState !== 'complete') {
+        addEventListener('load', () => whenReady(callback), true);
+    } else {
+        // Wait for the load event to end so that loadEventEnd is set.
+        setTimeout(callback, 0);
+    }
+}
+
+export function onTTFB(onReport, opts) {
+    opts = opts || {};
+    const metric = initMetric('TTFB');
+    const report = bindReporter(onReport, metric, opts.reportAllChanges);
+    whenReady(() => {
+        const nav = getNavigationEntry();
+        if (!nav) {
+            return;
+        }
+        metric.value = Math.max(nav.responseStart - getActivationStart(), 0);
+        if (metric.value < 0 || metric.value > performance.now()) {
+            return;
+        }
+        metric.entries = [nav];
+        report(true);
+    });
+}
@@ -0,0 +1,40 @@
+// Commands understood by the workers of the /workers page. This is a
+// classic script, loaded with importScripts by classic workers and imported
+// for its side effects by module workers.
+
+self.workerCommands = function (kind, post) {
+    return async function (command) {
+        switch (command.cmd) {
+            case 'fetch': {
+                try {
+                    const res = await fetch('/workers/data?from=' + kind);
+                    post({ type: 'fetched', kind: kind, status: res.status, body: await res.json() });
+                } catch (error) {
+                    post({ type: 'fetch-failed', kind: kind, error: String(error) });
+                }
+                break;
+            }
+            case 'log': {
+                console.log(kind + ' worker log');
+                console.info(kind + ' worker info');
+                console.warn(kind + ' worker warning');
+                console.error(kind + ' worker error');
+                post({ type: 'logged', kind: kind });
+                break;
+            }
+            case 'throw': {
+                post({ type: 'throwing', kind: kind });
+                // Errors thrown from a task, rather than from this async
+                // function, are uncaught and reach the worker's error event.
+                setTimeout(() => {
+                    throw new Error(kind + ' worker uncaught error');
+                }, 0);
+                break;
+            }
+            default: {
+                post({ type: 'echo', kind: kind, data: command.data });
+                break;
+            }
+        }
+    };
+};
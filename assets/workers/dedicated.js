@@ -0,0 +1,7 @@
+importScripts('/assets/workers/commands.js');
+
+const run = self.workerCommands('dedicated', (msg) => self.postMessage(msg));
+self.addEventListener('message', (event) => run(event.data));
+
+console.log('dedicated worker started');
+run({ cmd: 'fetch' });
@@ -0,0 +1,7 @@
+import './commands.js';
+
+const run = self.workerCommands('module', (msg) => self.postMessage(msg));
+self.addEventListener('message', (event) => run(event.data));
+
+console.log('module worker started');
+run({ cmd: 'fetch' });
@@ -0,0 +1,15 @@
+importScripts('/assets/workers/commands.js');
+
+let connections = 0;
+
+self.addEventListener('connect', (event) => {
+    const port = event.ports[0];
+    connections++;
+
+    const run = self.workerCommands('shared', (msg) => port.postMessage(Object.assign({ connections: connections }, msg)));
+    port.addEventListener('message', (event) => run(event.data));
+    port.start();
+
+    console.log('shared worker connection ' + connections);
+    run({ cmd: 'fetch' });
+});
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported values for the Content-Encoding header, in the order the
+// server prefers them when the client accepts more than one.
+const (
+	encodingBrotli   = "br"
+	encodingGzip     = "gzip"
+	encodingDeflate  = "deflate"
+	encodingIdentity = "identity"
+)
+
+var preferredEncodings = []string{encodingBrotli, encodingGzip, encodingDeflate}
+
+// negotiation records the outcome of a single content encoding
+// negotiation made by the compress middleware.
+type negotiation struct {
+	Time           time.Time `json:"time"`
+	Method         string    `json:"method"`
+	URL            string    `json:"url"`
+	AcceptEncoding string    `json:"acceptEncoding"`
+	Forced         bool      `json:"forced"`
+	Encoding       string    `json:"encoding"`
+	DecodedSize    int64     `json:"decodedSize"`
+	EncodedSize    int64     `json:"encodedSize"`
+}
+
+// compress encodes the response of next with the best encoding from the
+// request's Accept-Encoding header. The "encoding" query parameter forces a
+// specific encoding regardless of what the client accepts, and "compress=off"
+// disables compression altogether. The encoding actually used is sent back in
+// the X-Negotiated-Encoding header and recorded in the negotiation log.
+func (app *application) compress(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		accept := r.Header.Get("Accept-Encoding")
+
+		var (
+			encoding = negotiateEncoding(accept)
+			forced   bool
+		)
+		if e := q.Get("encoding"); e != "" {
+			encoding, forced = e, true
+		}
+		if q.Get("compress") == "off" {
+			encoding, forced = encodingIdentity, true
+		}
+		if !isSupportedEncoding(encoding) {
+			http.Error(w, fmt.Sprintf("unsupported encoding %q", encoding), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		if err := cw.Close(); err != nil {
+			slog.ErrorContext(r.Context(), "cannot close encoder", "encoding", encoding, "err", err)
+		}
+
+		app.negotiations.add(negotiation{
+			Time:           time.Now(),
+			Method:         r.Method,
+			URL:            r.URL.String(),
+			AcceptEncoding: accept,
+			Forced:         forced,
+			Encoding:       cw.encoding,
+			DecodedSize:    cw.decoded,
+			EncodedSize:    cw.encoded.n,
+		})
+		if e := journalEntryFrom(r.Context()); e != nil {
+			e.DecodedBodySize = cw.decoded
+		}
+	})
+}
+
+// negotiateEncoding picks the preferred encoding accepted by the client
+// according to the q-values in the Accept-Encoding header.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		qv := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				qv = f
+			}
+		}
+		accepted[name] = qv
+	}
+
+	best, bestQ := encodingIdentity, 0.0
+	for _, e := range preferredEncodings {
+		qv, ok := accepted[e]
+		if !ok {
+			qv, ok = accepted["*"]
+		}
+		if ok && qv > bestQ {
+			best, bestQ = e, qv
+		}
+	}
+
+	return best
+}
+
+func isSupportedEncoding(encoding string) bool {
+	switch encoding {
+	case encodingBrotli, encodingGzip, encodingDeflate, encodingIdentity:
+		return true
+	}
+	return false
+}
+
+// newEncoder returns a writer which encodes everything written to it into w.
+func newEncoder(w io.Writer, encoding string) io.WriteCloser {
+	switch encoding {
+	case encodingBrotli:
+		return brotli.NewWriter(w)
+	case encodingGzip:
+		return gzip.NewWriter(w)
+	case encodingDeflate:
+		// HTTP's deflate content coding is the zlib format.
+		return zlib.NewWriter(w)
+	}
+	return nopWriteCloser{w}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// compressResponseWriter encodes the response body on the fly. The encoder
+// is only set up once the handler writes the header, so that handlers which
+// already set their own Content-Encoding are passed through untouched.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	encoding    string
+	wroteHeader bool
+	enc         io.WriteCloser
+	encoded     countingWriter
+	decoded     int64
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.encoding = encodingIdentity
+	}
+	h.Set("X-Negotiated-Encoding", cw.encoding)
+	if cw.encoding != encodingIdentity {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+	}
+
+	cw.encoded.w = cw.ResponseWriter
+	cw.enc = newEncoder(&cw.encoded, cw.encoding)
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	cw.decoded += int64(len(p))
+	return cw.enc.Write(p)
+}
+
+// Flush flushes any buffered encoded data to the client so that streaming
+// handlers keep working behind the middleware. Flushing before the first
+// write sends the header, which must be labelled with the encoding first.
+func (cw *compressResponseWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressResponseWriter) Close() error {
+	if cw.enc == nil {
+		return nil
+	}
+	return cw.enc.Close()
+}
+
+// compressionTextHandler responds with a highly compressible plain text body
+// of the size (in bytes) given by the "size" query parameter.
+func (app *application) compressionTextHandler(w http.ResponseWriter, r *http.Request) {
+	size := 64 * 1024
+	if s := r.URL.Query().Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid size", http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	line := []byte("The quick brown fox jumps over the lazy dog.\n")
+	for size > 0 {
+		n := len(line)
+		if n > size {
+			n = size
+		}
+		if _, err := w.Write(line[:n]); err != nil {
+			return
+		}
+		size -= n
+	}
+}
+
+// compressionMislabelledHandler sends a body encoded with the "actual"
+// encoding while labelling it with the "label" encoding, so that the browser
+// either fails to decode it or reports misleading sizes.
+func (app *application) compressionMislabelledHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	label, actual := q.Get("label"), q.Get("actual")
+	if label == "" {
+		label = encodingGzip
+	}
+	if actual == "" {
+		actual = encodingIdentity
+	}
+	if !isSupportedEncoding(actual) {
+		http.Error(w, fmt.Sprintf("unsupported encoding %q", actual), http.StatusBadRequest)
+		return
+	}
+
+	body := encodeBody(compressionFixtureBody(), actual)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Content-Encoding", label)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	_, _ = w.Write(body)
+}
+
+// compressionCorruptHandler sends a correctly labelled body whose encoded
+// bytes have been damaged according to the "mode" query parameter:
+// "truncate" cuts the stream in half and "flip" inverts bytes in the middle.
+func (app *application) compressionCorruptHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	encoding := q.Get("encoding")
+	if encoding == "" {
+		encoding = encodingGzip
+	}
+	if !isSupportedEncoding(encoding) || encoding == encodingIdentity {
+		http.Error(w, fmt.Sprintf("unsupported encoding %q", encoding), http.StatusBadRequest)
+		return
+	}
+
+	body := encodeBody(compressionFixtureBody(), encoding)
+	switch mode := q.Get("mode"); mode {
+	case "", "truncate":
+		body = body[:len(body)/2]
+	case "flip":
+		for i := len(body) / 3; i < len(body)/3+16 && i < len(body); i++ {
+			body[i] = ^body[i]
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown mode %q", mode), http.StatusBadRequest)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Content-Encoding", encoding)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	_, _ = w.Write(body)
+}
+
+// compressionLogHandler returns the recorded negotiations as JSON. A DELETE
+// request clears the log.
+func (app *application) compressionLogHandler(w http.ResponseWriter, r *http.Request) {
+	serveRecords(w, r, &app.negotiations)
+}
+
+func compressionFixtureBody() []byte {
+	return bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\n"), 1024)
+}
+
+func encodeBody(body []byte, encoding string) []byte {
+	var buf bytes.Buffer
+	enc := newEncoder(&buf, encoding)
+	_, _ = enc.Write(body)
+	_ = enc.Close()
+	return buf.Bytes()
+}
+
+func (app *application) compressionHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "compression", nil)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// downloadLine is repeated to produce the contents of every download, so
+// that tests can compute the expected file contents from its size alone.
+const downloadLine = "testserver download fixture\n"
+
+// maxDownloadSize is the largest download served. The whole body is hashed
+// before being sent, so sizes must stay reasonable.
+const maxDownloadSize = 1 << 30
+
+// downloadReader returns a reader over size bytes of download contents.
+func downloadReader(size int64) io.Reader {
+	return io.LimitReader(repeatReader(downloadLine), size)
+}
+
+// downloadSHA256 returns the hex encoded SHA-256 of size bytes of download
+// contents.
+func downloadSHA256(size int64) string {
+	h := sha256.New()
+	_, _ = io.Copy(h, downloadReader(size))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type repeatReader string
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		n += copy(p[n:], r)
+	}
+	return n, nil
+}
+
+// parseSize returns the value of the query parameter key as a byte count,
+// or def if the parameter is missing.
+func parseSize(r *http.Request, key string, def int64) (int64, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, s)
+	}
+	return n, nil
+}
+
+// parseDuration returns the value of the query parameter key as a duration,
+// or def if the parameter is missing. Plain numbers are read as milliseconds.
+func parseDuration(r *http.Request, key string, def time.Duration) (time.Duration, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return def, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, s)
+	}
+	return d, nil
+}
+
+// parseDownloadSize returns the "size" query parameter, or def if missing.
+func parseDownloadSize(r *http.Request, def int64) (int64, error) {
+	size, err := parseSize(r, "size", def)
+	if err != nil {
+		return 0, err
+	}
+	if size > maxDownloadSize {
+		return 0, fmt.Errorf("size %d exceeds %d", size, int64(maxDownloadSize))
+	}
+	return size, nil
+}
+
+// contentDisposition builds an attachment Content-Disposition header for
+// name. Non ASCII names get an RFC 5987 filename* parameter along with an
+// ASCII fallback, unless mode asks for only one of the two.
+func contentDisposition(name, mode string) string {
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	utf8 := "UTF-8''" + strings.ReplaceAll(url.QueryEscape(name), "+", "%20")
+
+	switch mode {
+	case "ascii":
+		return fmt.Sprintf(`attachment; filename="%s"`, ascii)
+	case "utf8":
+		return "attachment; filename*=" + utf8
+	}
+	if ascii == name {
+		return fmt.Sprintf(`attachment; filename="%s"`, ascii)
+	}
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=%s`, ascii, utf8)
+}
+
+func setDownloadHeaders(w http.ResponseWriter, size int64) {
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Content-Length", strconv.FormatInt(size, 10))
+	h.Set("X-Content-SHA256", downloadSHA256(size))
+}
+
+// downloadFileHandler sends an attachment whose name is taken from the
+// "name" query parameter. "disposition" selects how the name is encoded:
+// "ascii", "utf8" or both (the default).
+func (app *application) downloadFileHandler(w http.ResponseWriter, r *http.Request) {
+	size, err := parseDownloadSize(r, 1024)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "download.txt"
+	}
+
+	setDownloadHeaders(w, size)
+	w.Header().Set("Content-Disposition", contentDisposition(name, r.URL.Query().Get("disposition")))
+	_, _ = io.Copy(w, downloadReader(size))
+}
+
+// downloadRawHandler sends the download contents without a
+// Content-Disposition header, for use with <a download>.
+func (app *application) downloadRawHandler(w http.ResponseWriter, r *http.Request) {
+	size, err := parseDownloadSize(r, 1024)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	setDownloadHeaders(w, size)
+	_, _ = io.Copy(w, downloadReader(size))
+}
+
+// downloadSlowHandler streams a large attachment in chunks of "chunk" bytes,
+// waiting "delay" between each of them. The whole download still has to fit
+// in the server's write timeout.
+func (app *application) downloadSlowHandler(w http.ResponseWriter, r *http.Request) {
+	size, err := parseDownloadSize(r, 10<<20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chunk, err := parseSize(r, "chunk", 64<<10)
+	if err != nil || chunk == 0 {
+		http.Error(w, "invalid chunk", http.StatusBadRequest)
+		return
+	}
+	delay, err := parseDuration(r, "delay", 100*time.Millisecond)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	setDownloadHeaders(w, size)
+	w.Header().Set("Content-Disposition", contentDisposition("slow.txt", ""))
+
+	body := downloadReader(size)
+	for {
+		n, err := io.CopyN(w, body, chunk)
+		if n > 0 {
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// downloadFailHandler announces an attachment of "size" bytes but aborts the
+// connection after sending "after" bytes of it.
+func (app *application) downloadFailHandler(w http.ResponseWriter, r *http.Request) {
+	size, err := parseDownloadSize(r, 1<<20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := parseSize(r, "after", size/2)
+	if err != nil || after > size {
+		http.Error(w, "invalid after", http.StatusBadRequest)
+		return
+	}
+
+	setDownloadHeaders(w, size)
+	w.Header().Set("Content-Disposition", contentDisposition("fail.txt", ""))
+
+	_, _ = io.CopyN(w, downloadReader(size), after)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	// Aborting the handler makes the server drop the connection without
+	// completing the response.
+	panic(http.ErrAbortHandler)
+}
+
+func (app *application) downloadHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "download", nil)
+}
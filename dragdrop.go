@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// dragState is the state of a drag and drop fixture after an item was
+// dropped, such as the items of every drop zone or the order of a list.
+type dragState struct {
+	Time    time.Time `json:"time"`
+	Fixture string    `json:"fixture"`
+	State   any       `json:"state"`
+}
+
+// dragAndDropHandler serves a page with HTML5 draggable items and drop
+// zones, a list sorted with pointer events, and a file drop zone. Each of
+// them shows its state on the page and posts it to /drag-and-drop/state
+// after every drop. Dropped files are also uploaded to /upload/submit.
+func (app *application) dragAndDropHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "drag-and-drop", nil)
+}
+
+// dragAndDropStateHandler records a dragState posted as JSON, and returns
+// the recorded states as JSON. A DELETE request clears them.
+func (app *application) dragAndDropStateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		serveRecords(w, r, &app.dragStates)
+		return
+	}
+
+	var s dragState
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Time = time.Now()
+	app.dragStates.add(s)
+
+	w.WriteHeader(http.StatusNoContent)
+}
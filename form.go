@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxFormSize is the largest form submission parsed by the form sink.
+const maxFormSize = 32 << 20
+
+// formField is a single submitted form value. Files are reported with their
+// name and size instead of their contents.
+type formField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	File  bool   `json:"file,omitempty"`
+	Size  int64  `json:"size,omitempty"`
+}
+
+// formSubmission is what the form sink echoes back.
+type formSubmission struct {
+	Method      string      `json:"method"`
+	ContentType string      `json:"contentType"`
+	Fields      []formField `json:"fields"`
+}
+
+// formSubmitHandler accepts GET and POST submissions encoded as query
+// parameters, application/x-www-form-urlencoded, multipart/form-data or
+// JSON, and echoes back the parsed fields. The response is JSON if the
+// client asks for it in its Accept header, and an HTML table otherwise.
+func (app *application) formSubmitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sub, err := parseFormSubmission(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sub); err != nil {
+			slog.ErrorContext(r.Context(), "cannot encode form submission", "err", err)
+		}
+		return
+	}
+
+	app.render(w, "form-result", sub)
+}
+
+func parseFormSubmission(w http.ResponseWriter, r *http.Request) (*formSubmission, error) {
+	sub := &formSubmission{Method: r.Method, Fields: []formField{}}
+	if r.Method == http.MethodGet {
+		sub.Fields = append(sub.Fields, valuesToFields(r.URL.Query())...)
+		return sub, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	sub.ContentType = ct
+
+	switch ct {
+	case "application/json":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("cannot decode JSON body: %w", err)
+		}
+		for _, k := range sortedKeys(body) {
+			sub.Fields = append(sub.Fields, jsonToFields(k, body[k])...)
+		}
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxFormSize); err != nil {
+			return nil, err
+		}
+		sub.Fields = append(sub.Fields, valuesToFields(r.MultipartForm.Value)...)
+		for _, k := range sortedKeys(r.MultipartForm.File) {
+			for _, fh := range r.MultipartForm.File[k] {
+				sub.Fields = append(sub.Fields, formField{Name: k, Value: fh.Filename, File: true, Size: fh.Size})
+			}
+		}
+	case "application/x-www-form-urlencoded", "":
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		sub.Fields = append(sub.Fields, valuesToFields(r.PostForm)...)
+	default:
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		sub.Fields = append(sub.Fields, formField{Name: "body", Value: string(b)})
+	}
+
+	return sub, nil
+}
+
+func valuesToFields(values url.Values) []formField {
+	var fields []formField
+	for _, k := range sortedKeys(values) {
+		for _, v := range values[k] {
+			fields = append(fields, formField{Name: k, Value: v})
+		}
+	}
+	return fields
+}
+
+// jsonToFields flattens a decoded JSON value into fields, using dotted names
+// for nested objects and repeating the name for array elements.
+func jsonToFields(name string, v any) []formField {
+	switch v := v.(type) {
+	case map[string]any:
+		var fields []formField
+		for _, k := range sortedKeys(v) {
+			fields = append(fields, jsonToFields(name+"."+k, v[k])...)
+		}
+		return fields
+	case []any:
+		var fields []formField
+		for _, e := range v {
+			fields = append(fields, jsonToFields(name, e)...)
+		}
+		return fields
+	case string:
+		return []formField{{Name: name, Value: v}}
+	default:
+		b, _ := json.Marshal(v)
+		return []formField{{Name: name, Value: string(b)}}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (app *application) formHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "form", nil)
+}
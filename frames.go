@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFrames is the largest number of frames a generated frame tree can have.
+const maxFrames = 500
+
+// frameNode describes a frame the way page.frames() reports it: by name
+// and URL, along with its child frames.
+type frameNode struct {
+	Name     string      `json:"name"`
+	URL      string      `json:"url"`
+	Children []frameNode `json:"children"`
+}
+
+// frameTree is a generated tree of frames, with breadth child frames per
+// frame down to depth levels below the main frame.
+type frameTree struct {
+	depth   int
+	breadth int
+}
+
+func parseFrameTree(r *http.Request) (frameTree, error) {
+	depth, err := parseSize(r, "depth", 2)
+	if err != nil {
+		return frameTree{}, err
+	}
+	breadth, err := parseSize(r, "breadth", 2)
+	if err != nil {
+		return frameTree{}, err
+	}
+
+	// Reject deep trees up front: with a breadth of 0 the loop below never
+	// reaches maxFrames and would run depth times.
+	if depth > maxFrames {
+		return frameTree{}, fmt.Errorf("frame tree of depth %d exceeds %d frames", depth, maxFrames)
+	}
+
+	total, level := int64(0), int64(1)
+	for i := int64(0); i < depth; i++ {
+		level *= breadth
+		total += level
+		if total > maxFrames {
+			return frameTree{}, fmt.Errorf("frame tree of depth %d and breadth %d exceeds %d frames", depth, breadth, maxFrames)
+		}
+	}
+
+	return frameTree{depth: int(depth), breadth: int(breadth)}, nil
+}
+
+// src returns the URL of the frame at node, a dash separated path of child
+// indexes, or the main frame if node is empty.
+func (t frameTree) src(node string) string {
+	q := url.Values{}
+	q.Set("depth", strconv.Itoa(t.depth))
+	q.Set("breadth", strconv.Itoa(t.breadth))
+	if node != "" {
+		q.Set("node", node)
+	}
+	return "/frames/tree?" + q.Encode()
+}
+
+func (t frameTree) children(node string) []string {
+	level := 0
+	if node != "" {
+		level = strings.Count(node, "-") + 1
+	}
+	if level >= t.depth {
+		return nil
+	}
+
+	var children []string
+	for i := 0; i < t.breadth; i++ {
+		child := strconv.Itoa(i)
+		if node != "" {
+			child = node + "-" + child
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+func (t frameTree) expected(origin, node string) frameNode {
+	n := frameNode{URL: origin + t.src(node), Children: []frameNode{}}
+	if node != "" {
+		n.Name = "frame-" + node
+	}
+	for _, child := range t.children(node) {
+		n.Children = append(n.Children, t.expected(origin, child))
+	}
+	return n
+}
+
+// mixedFrames returns the frames of the mixed frames page once the
+// self-navigating frame has navigated.
+func mixedFrames(origin string) frameNode {
+	leaf := func(name string, q string) frameNode {
+		return frameNode{Name: name, URL: origin + "/frames/leaf?" + q, Children: []frameNode{}}
+	}
+	return frameNode{
+		URL: origin + "/frames/mixed",
+		Children: []frameNode{
+			leaf("named", "label=named"),
+			{Name: "srcdoc", URL: "about:srcdoc", Children: []frameNode{}},
+			{Name: "blank", URL: "about:blank", Children: []frameNode{}},
+			{Name: "written", URL: "about:blank", Children: []frameNode{}},
+			leaf("self-navigating", "label=self-navigating&navigated=1"),
+			leaf("sandboxed", "label=sandboxed"),
+			leaf("sandboxed-strict", "label=sandboxed-strict"),
+		},
+	}
+}
+
+// framesHandler serves frame fixtures:
+//
+//   - /frames/tree?depth=2&breadth=2 nests breadth frames in every frame,
+//     down to depth levels. Frames are named and identified as
+//     "frame-<path>", where path lists the index of each ancestor, such as
+//     frame-0-1.
+//   - /frames/mixed holds named, srcdoc, about:blank, self-navigating and
+//     sandboxed frames.
+//   - /frames/dynamic?interval=1000&lifetime=2000&count=5 attaches a frame
+//     every interval, and detaches each of them after its lifetime.
+//   - /frames/leaf?label=x is the page loaded in frames. With
+//     navigate=500 it navigates itself after that many milliseconds.
+//
+// Adding .json to the tree and mixed fixtures returns the frame tree
+// expected in the browser, once loaded, to compare with page.frames(). The
+// URLs of generated trees have their query parameters sorted, as the tree
+// page links to them.
+func (app *application) framesHandler(w http.ResponseWriter, r *http.Request) {
+	origin := "http://" + r.Host
+	if r.TLS != nil {
+		origin = "https://" + r.Host
+	}
+
+	switch name := strings.TrimPrefix(r.URL.Path, "/frames/"); name {
+	case "tree", "tree.json":
+		t, err := parseFrameTree(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name == "tree.json" {
+			writeFrameTree(w, r, t.expected(origin, ""))
+			return
+		}
+		app.frameTreeHandler(w, r, t)
+	case "mixed":
+		app.render(w, "frames-mixed", nil)
+	case "mixed.json":
+		writeFrameTree(w, r, mixedFrames(origin))
+	case "dynamic":
+		app.framesDynamicHandler(w, r)
+	case "leaf":
+		app.render(w, "frames-leaf", struct{ Label string }{r.URL.Query().Get("label")})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (app *application) frameTreeHandler(w http.ResponseWriter, r *http.Request, t frameTree) {
+	type frame struct {
+		Name string
+		Src  string
+	}
+	node := r.URL.Query().Get("node")
+	data := struct {
+		Node   string
+		Frames []frame
+	}{Node: node}
+	for _, child := range t.children(node) {
+		data.Frames = append(data.Frames, frame{Name: "frame-" + child, Src: t.src(child)})
+	}
+
+	app.render(w, "frames-tree", data)
+}
+
+func (app *application) framesDynamicHandler(w http.ResponseWriter, r *http.Request) {
+	interval, err := parseDuration(r, "interval", time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lifetime, err := parseDuration(r, "lifetime", 2*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	count, err := parseSize(r, "count", 5)
+	if err != nil || count > maxFrames {
+		http.Error(w, "invalid count", http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "frames-dynamic", struct {
+		Interval int64
+		Lifetime int64
+		Count    int64
+	}{interval.Milliseconds(), lifetime.Milliseconds(), count})
+}
+
+func writeFrameTree(w http.ResponseWriter, r *http.Request, tree frameNode) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(tree); err != nil {
+		slog.ErrorContext(r.Context(), "cannot encode frame tree", "err", err)
+	}
+}
+
+func (app *application) framesIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "frames", nil)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseFrameTree(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    frameTree
+		wantErr bool
+	}{
+		{query: "", want: frameTree{depth: 2, breadth: 2}},
+		{query: "depth=3&breadth=4", want: frameTree{depth: 3, breadth: 4}},
+		{query: "depth=0", want: frameTree{depth: 0, breadth: 2}},
+		{query: "depth=500&breadth=1", want: frameTree{depth: 500, breadth: 1}},
+		{query: "depth=500&breadth=0", want: frameTree{depth: 500, breadth: 0}},
+		{query: "depth=501&breadth=1", wantErr: true},
+		{query: "depth=1000000000000&breadth=0", wantErr: true},
+		{query: "depth=9&breadth=2", wantErr: true},
+		{query: "depth=1&breadth=501", wantErr: true},
+		{query: "depth=-1", wantErr: true},
+		{query: "breadth=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/frames/tree?"+tt.query, nil)
+		got, err := parseFrameTree(r)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFrameTree(%q) error = %v, want error %t", tt.query, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseFrameTree(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFrameTreeChildren(t *testing.T) {
+	tests := []struct {
+		tree frameTree
+		node string
+		want []string
+	}{
+		{tree: frameTree{depth: 2, breadth: 2}, node: "", want: []string{"0", "1"}},
+		{tree: frameTree{depth: 2, breadth: 3}, node: "1", want: []string{"1-0", "1-1", "1-2"}},
+		{tree: frameTree{depth: 2, breadth: 2}, node: "1-0", want: nil},
+		{tree: frameTree{depth: 0, breadth: 2}, node: "", want: nil},
+		{tree: frameTree{depth: 3, breadth: 0}, node: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := tt.tree.children(tt.node); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.children(%q) = %q, want %q", tt.tree, tt.node, got, tt.want)
+		}
+	}
+}
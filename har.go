@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The HAR 1.2 format, as described in
+// http://www.softwareishard.com/blog/har-12-spec/. Fields starting with an
+// underscore are custom ones, following the same conventions as Chrome's
+// DevTools.
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Connection      string      `json:"connection,omitempty"`
+
+	Namespace         string             `json:"_namespace,omitempty"`
+	TLS               *journalTLS        `json:"_tls,omitempty"`
+	WebSocketMessages []webSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harContent describes the decoded response body. Compression is the number
+// of bytes saved by its content encoding.
+type harContent struct {
+	Size        int64  `json:"size"`
+	Compression int64  `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+// harTimings are in milliseconds. Phases the server cannot observe, such as
+// DNS and connecting, are -1.
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// harHandler exports the journal as a HAR file. "since" and "until" limit
+// the export to requests started in that time range, given as RFC 3339
+// timestamps, and "namespace" to the requests of a single namespace.
+func (app *application) harHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var since, until time.Time
+	for _, p := range []struct {
+		key string
+		t   *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		s := q.Get(p.key)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s %q", p.key, s), http.StatusBadRequest)
+			return
+		}
+		*p.t = t
+	}
+	ns, filterNS := q.Get("namespace"), q.Has("namespace")
+
+	h := har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "testserver", Version: "1.0"},
+		Entries: []harEntry{},
+	}}
+	for _, e := range app.journalLog.list() {
+		if !since.IsZero() && e.Start.Before(since) ||
+			!until.IsZero() && e.Start.After(until) ||
+			filterNS && e.Namespace != ns {
+			continue
+		}
+		h.Log.Entries = append(h.Log.Entries, e.har())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", contentDisposition("testserver.har", "ascii"))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h); err != nil {
+		slog.ErrorContext(r.Context(), "cannot encode HAR", "err", err)
+	}
+}
+
+func (e *journalEntry) har() harEntry {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+	he := harEntry{
+		StartedDateTime: e.Start,
+		Time:            ms(e.Wait + e.Receive),
+		Request: harRequest{
+			Method:      e.Method,
+			URL:         e.URL,
+			HTTPVersion: e.Proto,
+			Cookies:     harCookies((&http.Request{Header: e.RequestHeader}).Cookies()),
+			Headers:     harHeaders(e.RequestHeader),
+			QueryString: harQuery(e.URL),
+			HeadersSize: -1,
+			BodySize:    e.RequestBodySize,
+		},
+		Response: harResponse{
+			Status:      e.Status,
+			StatusText:  http.StatusText(e.Status),
+			HTTPVersion: e.Proto,
+			Cookies:     harCookies((&http.Response{Header: e.ResponseHeader}).Cookies()),
+			Headers:     harHeaders(e.ResponseHeader),
+			Content: harContent{
+				Size:     e.ResponseBodySize,
+				MimeType: e.ResponseHeader.Get("Content-Type"),
+			},
+			RedirectURL: e.ResponseHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    e.ResponseBodySize,
+		},
+		Timings: harTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			SSL:     -1,
+			Wait:    ms(e.Wait),
+			Receive: ms(e.Receive),
+		},
+		Namespace:         e.Namespace,
+		TLS:               e.TLS,
+		WebSocketMessages: e.WebSocketMessages,
+	}
+	if e.DecodedBodySize > 0 {
+		he.Response.Content.Size = e.DecodedBodySize
+		he.Response.Content.Compression = e.DecodedBodySize - e.ResponseBodySize
+	}
+	if host, port, err := net.SplitHostPort(e.Local); err == nil {
+		he.ServerIPAddress, he.Connection = host, port
+	}
+	if e.RequestBodySize > 0 {
+		pd := &harPostData{MimeType: e.RequestHeader.Get("Content-Type"), Text: string(e.RequestBody)}
+		if !utf8.Valid(e.RequestBody) {
+			pd.Text, pd.Encoding = base64.StdEncoding.EncodeToString(e.RequestBody), "base64"
+		}
+		he.Request.PostData = pd
+	}
+	return he
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	nv := []harNameValue{}
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			nv = append(nv, harNameValue{Name: k, Value: v})
+		}
+	}
+	return nv
+}
+
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	nv := []harNameValue{}
+	for _, c := range cookies {
+		nv = append(nv, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	return nv
+}
+
+func harQuery(rawURL string) []harNameValue {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return []harNameValue{}
+	}
+	nv := []harNameValue{}
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		k, _ = url.QueryUnescape(k)
+		v, _ = url.QueryUnescape(v)
+		nv = append(nv, harNameValue{Name: k, Value: v})
+	}
+	return nv
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// inputEvent is a keyboard, mouse, pointer, wheel or touch event captured by
+// the /input-events page. Fields which don't apply to the type of event are
+// left empty.
+type inputEvent struct {
+	Time      time.Time `json:"time"`
+	Session   string    `json:"session"`
+	Seq       int       `json:"seq"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	TimeStamp float64   `json:"timeStamp"`
+	IsTrusted bool      `json:"isTrusted"`
+
+	AltKey   bool `json:"altKey"`
+	CtrlKey  bool `json:"ctrlKey"`
+	MetaKey  bool `json:"metaKey"`
+	ShiftKey bool `json:"shiftKey"`
+
+	Key       string `json:"key,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Location  int    `json:"location,omitempty"`
+	Repeat    bool   `json:"repeat,omitempty"`
+	InputType string `json:"inputType,omitempty"`
+	Data      string `json:"data,omitempty"`
+
+	Button  *int    `json:"button,omitempty"`
+	Buttons *int    `json:"buttons,omitempty"`
+	Detail  int     `json:"detail,omitempty"`
+	ClientX float64 `json:"clientX,omitempty"`
+	ClientY float64 `json:"clientY,omitempty"`
+	PageX   float64 `json:"pageX,omitempty"`
+	PageY   float64 `json:"pageY,omitempty"`
+	ScreenX float64 `json:"screenX,omitempty"`
+	ScreenY float64 `json:"screenY,omitempty"`
+	OffsetX float64 `json:"offsetX,omitempty"`
+	OffsetY float64 `json:"offsetY,omitempty"`
+
+	PointerID   int     `json:"pointerId,omitempty"`
+	PointerType string  `json:"pointerType,omitempty"`
+	IsPrimary   bool    `json:"isPrimary,omitempty"`
+	Pressure    float64 `json:"pressure,omitempty"`
+	Width       float64 `json:"width,omitempty"`
+	Height      float64 `json:"height,omitempty"`
+
+	DeltaX    float64 `json:"deltaX,omitempty"`
+	DeltaY    float64 `json:"deltaY,omitempty"`
+	DeltaZ    float64 `json:"deltaZ,omitempty"`
+	DeltaMode int     `json:"deltaMode,omitempty"`
+
+	Touches        []touchPoint `json:"touches,omitempty"`
+	ChangedTouches []touchPoint `json:"changedTouches,omitempty"`
+}
+
+type touchPoint struct {
+	Identifier int     `json:"identifier"`
+	ClientX    float64 `json:"clientX"`
+	ClientY    float64 `json:"clientY"`
+	Force      float64 `json:"force"`
+}
+
+// inputEventsHandler serves a page recording every input event it receives.
+// The events are listed on the page and posted to /input-events/log in
+// batches.
+func (app *application) inputEventsHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "input-events", nil)
+}
+
+// inputEventsLogHandler records the events posted as a JSON array, and
+// returns the recorded events as JSON. A DELETE request clears them.
+func (app *application) inputEventsLogHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		serveRecords(w, r, &app.inputEvents)
+		return
+	}
+
+	var events []inputEvent
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&events); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	for i := range events {
+		events[i].Time = now
+	}
+	app.inputEvents.add(events...)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// namespaceHeader is the request header tests set to tag their traffic in
+// the journal. Requests to mock routes are tagged with the namespace of the
+// route.
+const namespaceHeader = "X-Test-Namespace"
+
+// maxJournalBody is the largest request body kept by the journal.
+const maxJournalBody = 64 << 10
+
+// journalEntry is an exchange observed by the server. Entries are added to
+// the journal once their handler is done, so WebSocket connections only
+// appear once closed.
+type journalEntry struct {
+	Start     time.Time   `json:"start"`
+	Namespace string      `json:"namespace,omitempty"`
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Proto     string      `json:"proto"`
+	TLS       *journalTLS `json:"tls,omitempty"`
+	Remote    string      `json:"remote"`
+	Local     string      `json:"local,omitempty"`
+
+	RequestHeader   http.Header `json:"requestHeader"`
+	RequestBody     []byte      `json:"requestBody,omitempty"`
+	RequestBodySize int64       `json:"requestBodySize"`
+
+	Status           int         `json:"status"`
+	ResponseHeader   http.Header `json:"responseHeader"`
+	ResponseBodySize int64       `json:"responseBodySize"`
+
+	// DecodedBodySize is the size of the response body before the compress
+	// middleware encoded it, or 0 when the response went through unencoded.
+	DecodedBodySize int64 `json:"decodedBodySize,omitempty"`
+
+	// Wait is the time until the response header was written and Receive
+	// the time it then took to write the body.
+	Wait    time.Duration `json:"wait"`
+	Receive time.Duration `json:"receive"`
+
+	WebSocketMessages []webSocketMessage `json:"webSocketMessages,omitempty"`
+}
+
+type journalTLS struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipherSuite"`
+	ServerName  string `json:"serverName,omitempty"`
+	Protocol    string `json:"protocol,omitempty"`
+}
+
+// webSocketMessage is a message of a WebSocket connection. Type is "send"
+// for messages sent by the browser and "receive" for the ones sent by the
+// server, as browsers report them. Time is in seconds since the epoch, as
+// in Chrome's HAR files.
+type webSocketMessage struct {
+	Type   string  `json:"type"`
+	Time   float64 `json:"time"`
+	Opcode int     `json:"opcode"`
+	Data   string  `json:"data"`
+}
+
+type journalEntryKey struct{}
+
+// journalEntryFrom returns the journal entry of the request of ctx, if it is
+// being journaled.
+func journalEntryFrom(ctx context.Context) *journalEntry {
+	e, _ := ctx.Value(journalEntryKey{}).(*journalEntry)
+	return e
+}
+
+// addWebSocketMessage records a message of the WebSocket connection made by
+// the request of ctx. It must be called from the handler's goroutine.
+func addWebSocketMessage(ctx context.Context, typ string, opcode int, data []byte) {
+	e := journalEntryFrom(ctx)
+	if e == nil {
+		return
+	}
+	e.WebSocketMessages = append(e.WebSocketMessages, webSocketMessage{
+		Type:   typ,
+		Time:   float64(time.Now().UnixMicro()) / 1e6,
+		Opcode: opcode,
+		Data:   string(data),
+	})
+}
+
+// journal records every exchange handled by next, except for the ones
+// reading the journal itself.
+func (app *application) journal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/journal" || r.URL.Path == "/har" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		e := &journalEntry{
+			Start:         time.Now(),
+			Namespace:     requestNamespace(r),
+			Method:        r.Method,
+			URL:           requestURL(r),
+			Proto:         r.Proto,
+			TLS:           tlsInfo(r.TLS),
+			Remote:        r.RemoteAddr,
+			RequestHeader: r.Header.Clone(),
+		}
+		if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			e.Local = addr.String()
+		}
+
+		body := &journalBody{ReadCloser: r.Body}
+		r.Body = body
+		jw := &journalResponseWriter{ResponseWriter: w, entry: e}
+
+		// The entry is added even if next panics, such as when a handler
+		// aborts with http.ErrAbortHandler, and the panic carries on.
+		completed := false
+		defer func() {
+			end := time.Now()
+			if completed && !jw.wroteHeader {
+				jw.WriteHeader(http.StatusOK)
+			}
+			e.Receive = end.Sub(e.Start) - e.Wait
+			// Handlers don't always read the body, so read what the journal
+			// keeps of it and trust the Content-Length for its size.
+			if r.ContentLength != 0 {
+				_, _ = io.CopyN(io.Discard, body, int64(maxJournalBody-body.buf.Len()))
+			}
+			e.RequestBody = body.buf.Bytes()
+			e.RequestBodySize = body.n
+			if r.ContentLength >= 0 {
+				e.RequestBodySize = r.ContentLength
+			}
+
+			app.journalLog.add(e)
+		}()
+
+		next.ServeHTTP(jw, r.WithContext(context.WithValue(r.Context(), journalEntryKey{}, e)))
+		completed = true
+	})
+}
+
+func requestNamespace(r *http.Request) string {
+	if ns := r.Header.Get(namespaceHeader); ns != "" {
+		return ns
+	}
+	if strings.HasPrefix(r.URL.Path, "/mock/") {
+		ns, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/mock/"), "/")
+		return ns
+	}
+	return ""
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if r.Header.Get("Upgrade") != "" {
+		scheme = strings.Replace(scheme, "http", "ws", 1)
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func tlsInfo(cs *tls.ConnectionState) *journalTLS {
+	if cs == nil {
+		return nil
+	}
+	return &journalTLS{
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ServerName:  cs.ServerName,
+		Protocol:    cs.NegotiatedProtocol,
+	}
+}
+
+// journalBody counts the bytes read from a request body and keeps the first
+// maxJournalBody of them.
+type journalBody struct {
+	io.ReadCloser
+
+	n   int64
+	buf bytes.Buffer
+}
+
+func (b *journalBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if room := maxJournalBody - b.buf.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	return n, err
+}
+
+// journalResponseWriter records the status, headers, size and timings of a
+// response.
+type journalResponseWriter struct {
+	http.ResponseWriter
+
+	entry       *journalEntry
+	wroteHeader bool
+}
+
+func (jw *journalResponseWriter) WriteHeader(status int) {
+	if jw.wroteHeader {
+		return
+	}
+	jw.wroteHeader = true
+	jw.entry.Status = status
+	jw.entry.ResponseHeader = jw.Header().Clone()
+	jw.entry.Wait = time.Since(jw.entry.Start)
+	jw.ResponseWriter.WriteHeader(status)
+}
+
+func (jw *journalResponseWriter) Write(p []byte) (int, error) {
+	if !jw.wroteHeader {
+		jw.WriteHeader(http.StatusOK)
+	}
+	n, err := jw.ResponseWriter.Write(p)
+	jw.entry.ResponseBodySize += int64(n)
+	return n, err
+}
+
+func (jw *journalResponseWriter) Flush() {
+	if f, ok := jw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades through. The upgrade response is written
+// to the hijacked connection, so it is assumed to be a 101.
+func (jw *journalResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := jw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil && !jw.wroteHeader {
+		jw.wroteHeader = true
+		jw.entry.Status = http.StatusSwitchingProtocols
+		jw.entry.ResponseHeader = jw.Header().Clone()
+		jw.entry.Wait = time.Since(jw.entry.Start)
+	}
+	return conn, rw, err
+}
+
+// journalHandler returns the journal as JSON. A DELETE request clears it.
+func (app *application) journalHandler(w http.ResponseWriter, r *http.Request) {
+	serveRecords(w, r, &app.journalLog)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// requestIDHeader carries the ID of a request. Clients can set it to
+// correlate their own logs with the server's, otherwise the server makes one
+// up. Either way it is sent back in the response.
+const requestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// requestID returns the ID of the request of ctx.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newLogger returns a logger writing to w in the given format, "text"
+// (logfmt) or "json", and discarding records below level.
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch format {
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// contextHandler adds the ID of the request being handled to the records
+// logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// fatal logs msg as an error and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// quietPaths is a comma separated list of paths whose requests are not
+// access logged. Paths ending with a slash match everything below them.
+type quietPaths []string
+
+func (q *quietPaths) String() string {
+	return strings.Join(*q, ",")
+}
+
+func (q *quietPaths) Set(s string) error {
+	*q = nil
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			*q = append(*q, p)
+		}
+	}
+	return nil
+}
+
+func (q quietPaths) match(path string) bool {
+	for _, p := range q {
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// logRequests assigns every request an ID and logs it once handled, unless
+// its path is quiet.
+func (app *application) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		// Requests whose handler panics, such as when it aborts with
+		// http.ErrAbortHandler, are logged too and the panic carries on.
+		completed := false
+		defer func() {
+			if app.quietPaths.match(r.URL.Path) {
+				return
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("host", r.Host),
+				slog.String("path", r.URL.Path),
+				slog.String("query", r.URL.RawQuery),
+				slog.String("proto", r.Proto),
+				slog.Bool("tls", r.TLS != nil),
+				slog.String("remote", r.RemoteAddr),
+				slog.Int("status", rw.status),
+				slog.Int64("bytes", rw.n),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if ns := requestNamespace(r); ns != "" {
+				attrs = append(attrs, slog.String("namespace", ns))
+			}
+			if !completed {
+				attrs = append(attrs, slog.Bool("panicked", true))
+			}
+			slog.LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
+		}()
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		completed = true
+	})
+}
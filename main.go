@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -19,8 +29,22 @@ type application struct {
 		password string
 	}
 
+	templates *templates
+
 	counterMu *sync.Mutex
 	counter   int
+
+	negotiations  recordLog[negotiation]
+	uploads       uploadStore
+	dialogResults recordLog[dialogResult]
+	inputEvents   recordLog[inputEvent]
+	dragStates    recordLog[dragState]
+	mocks         mockStore
+	journalLog    recordLog[*journalEntry]
+	metrics       *metrics
+	quietPaths    quietPaths
+	webSockets    webSockets
+	ready         atomic.Bool
 }
 
 var upgrader = websocket.Upgrader{
@@ -29,39 +53,82 @@ var upgrader = websocket.Upgrader{
 }
 
 func main() {
+	templatesDir := flag.String("templates", "", "directory of templates overriding or adding to the embedded ones")
+	var static staticMounts
+	flag.Var(&static, "static", "serve a directory at a URL prefix, as prefix=dir (repeatable)")
+	var proxyCfg proxyConfig
+	flag.StringVar(&proxyCfg.upstream, "proxy-upstream", "", "URL of the upstream to proxy and record")
+	flag.StringVar(&proxyCfg.mode, "proxy-mode", "", "proxy mode: record (the default with -proxy-upstream) or replay")
+	flag.StringVar(&proxyCfg.dir, "proxy-dir", "recordings", "directory of the proxy recordings")
+	flag.StringVar(&proxyCfg.host, "proxy-host", "proxy.localhost", "host name served by the proxy")
+	flag.StringVar(&proxyCfg.match, "proxy-match", "method,path,query", "request parts matched when replaying, out of method, path, query and body")
+	flag.BoolVar(&proxyCfg.replayTiming, "proxy-replay-timing", false, "replay responses with their recorded durations")
+	journalSize := flag.Int("journal-size", 10000, "number of requests kept in the request journal")
+	logFormat := flag.String("log-format", "text", "log format: text (logfmt) or json")
+	logLevel := flag.String("log-level", "info", "lowest level logged: debug, info, warn or error")
+	var quiet quietPaths
+	flag.Var(&quiet, "log-quiet", "comma separated paths whose requests are not logged, such as /ping,/metrics")
+	drainTimeout := flag.Duration("drain-timeout", 10*time.Second, "time given to requests in flight and WebSocket connections to finish on shutdown")
+	flag.Parse()
+
+	logger, err := newLogger(os.Stderr, *logFormat, *logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
 	app := new(application)
+	app.templates = &templates{dir: *templatesDir}
 
 	app.auth.username = os.Getenv("AUTH_USERNAME")
 	app.auth.password = os.Getenv("AUTH_PASSWORD")
 	app.counterMu = &sync.Mutex{}
+	app.journalLog.limit = *journalSize
+	// Mouse and pointer moves quickly add up to thousands of events.
+	app.inputEvents.limit = 10 * maxRecords
+	app.metrics = newMetrics(app)
+	app.quietPaths = quiet
 
 	if app.auth.username == "" {
-		log.Fatal("basic auth username must be provided")
+		fatal("basic auth username must be provided")
 	}
 
 	if app.auth.password == "" {
-		log.Fatal("basic auth password must be provided")
+		fatal("basic auth password must be provided")
+	}
+
+	set, err := app.templates.get()
+	if err != nil {
+		fatal("cannot load templates", "err", err)
+	}
+	for _, page := range set.custom {
+		slog.Info("serving custom page", "path", "/"+page)
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", app.indexHandler)
-	mux.HandleFunc("/csp", app.cspHandler)
-	mux.HandleFunc("/other", app.otherHandler)
-	mux.HandleFunc("/protected", app.basicAuth(app.protectedHandler))
-	mux.HandleFunc("/slow", app.slowHandler)
-	mux.HandleFunc("/ws/echo", app.wsEchoHandler)
-	mux.HandleFunc("/embed-youtube", app.embedYoutubeHandler)
-	mux.HandleFunc("/ping-main-html", app.pingMainHtmlHandler)
-	mux.HandleFunc("/ping", app.pingHandler)
-	mux.HandleFunc("/ping-html", app.pingHtmlHandler)
-	mux.HandleFunc("/ping.js", app.pingJSHandler)
-	mux.HandleFunc("/textbox", app.textBoxHandler)
-	mux.HandleFunc("/dialogbox", app.dialogBoxHandler)
-	mux.HandleFunc("/robots.txt", app.robotstxt)
+	for _, rt := range app.routes() {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+	for _, m := range static {
+		slog.Info("serving static directory", "dir", m.dir, "prefix", m.prefix)
+		mux.HandleFunc(m.prefix, app.staticHandler(m))
+	}
+	if proxyCfg.upstream != "" || proxyCfg.mode != "" {
+		p, err := newProxy(proxyCfg)
+		if err != nil {
+			fatal("cannot start proxy", "err", err)
+		}
+		slog.Info("proxy started", "mode", p.cfg.mode, "host", proxyCfg.host)
+		mux.Handle(proxyCfg.host+"/", p)
+	}
+
+	handler := app.logRequests(app.journal(app.metrics.instrument(mux)))
 
 	srv := &http.Server{
 		Addr:         ":80",
-		Handler:      mux,
+		Handler:      handler,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
@@ -69,227 +136,130 @@ func main() {
 
 	srvS := &http.Server{
 		Addr:         ":443",
-		Handler:      mux,
+		Handler:      handler,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
-	var wg sync.WaitGroup
+	// Certificates are loaded and listeners opened up front, so that the
+	// server only reports ready once both of them accept connections.
+	cert, err := tls.LoadX509KeyPair("./localhost.pem", "./localhost-key.pem")
+	if err != nil {
+		fatal("cannot load TLS certs", "err", err)
+	}
+	srvS.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	lnS, err := net.Listen("tcp", srvS.Addr)
+	if err != nil {
+		fatal("cannot listen", "addr", srvS.Addr, "err", err)
+	}
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		fatal("cannot listen", "addr", srv.Addr, "err", err)
+	}
 
-		log.Printf("starting server on %s with self signed TLS certs", srvS.Addr)
-		err := srvS.ListenAndServeTLS("./localhost.pem", "./localhost-key.pem")
-		log.Fatal(err)
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	wg.Add(1)
+	errs := make(chan error, 2)
 	go func() {
-		defer wg.Done()
-
-		log.Printf("starting server on %s with no TLS", srv.Addr)
-		err := srv.ListenAndServe()
-		log.Fatal(err)
+		slog.Info("starting server with self signed TLS certs", "addr", srvS.Addr)
+		errs <- srvS.ServeTLS(lnS, "", "")
+	}()
+	go func() {
+		slog.Info("starting server with no TLS", "addr", srv.Addr)
+		errs <- srv.Serve(ln)
 	}()
+	app.ready.Store(true)
+
+	select {
+	case <-ctx.Done():
+		stop()
+	case err := <-errs:
+		if !isServerClosed(err) {
+			fatal("server stopped", "err", err)
+		}
+	}
+
+	app.shutdown(*drainTimeout, srv, srvS)
+}
 
-	wg.Wait()
+// indexLink is a link to a page listed on the index page.
+type indexLink struct {
+	ID          string
+	Href        string
+	Description string
 }
 
+// indexHandler serves the index page, listing the named routes of the
+// registry followed by the custom pages. Custom pages are also served from
+// here, as they aren't registered on the mux.
 func (app *application) indexHandler(w http.ResponseWriter, r *http.Request) {
+	set, err := app.templates.get()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "cannot load templates", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
 	if r.URL.Path != "/" {
+		if page := strings.TrimPrefix(r.URL.Path, "/"); set.isCustomPage(page) {
+			app.render(w, page, nil)
+			return
+		}
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 	v := r.Header[http.CanonicalHeaderKey("x-authenticated-user")]
 	if v != nil {
-		fmt.Printf("x-authenticated-user header present in call to index: %s\n", v)
+		slog.DebugContext(r.Context(), "x-authenticated-user header present in call to index", "user", v[0])
 		w.Header().Add("x-authenticated-user", v[0])
 	}
 
-	fmt.Fprintf(w, `
-	<!DOCTYPE html>
-<html>
-
-<head>
-    <meta name="robots" content="noindex, nofollow" />
-</head>
-
-<body>
-    <table>
-        <tr>
-            <td><a id="csp" href="/csp">/csp</a></td>
-            <td><a id="csp_np" href="/csp" target="_blank">/csp</a> (new tab)</td>
-            <td>Test CSP (look in console)</td>
-        </tr>
-        <tr>
-            <td><a id="other" href="/other">/other</a></td>
-            <td><a id="other_np" href="/other" target="_blank">/other</a> (new tab)</td>
-            <td>Go here for most tests</td>
-        </tr>
-        <tr>
-            <td><a id="protected" href="/protected">/protected</a></td>
-            <td><a id="protected_np" href="/protected" target="_blank">/protected</a> (new tab)</td>
-            <td>Test for basic auth</td>
-        </tr>
-        <tr>
-            <td><a id="slow" href="/slow">/slow</a></td>
-            <td><a id="slow_np" href="/slow" target="_blank">/slow</a> (new tab)</td>
-            <td>You'll get a response back after 200ms</td>
-        </tr>
-        <tr>
-            <td><a id="dialogbox" href="/dialogbox">/dialogbox</a></td>
-            <td><a id="dialogbox_np" href="/dialogbox" target="_blank">/dialogbox</a> (new tab)</td>
-            <td>A page with a dialog box</td>
-        </tr>
-        <tr>
-            <td><a id="embed_youtube_np" href="/embed-youtube">/embed-youtube</a></td>
-            <td><a id="embed_youtube_np" href="/embed-youtube" target="_blank">/embed-youtube</a> (new tab)</td>
-            <td>A page with a embedded Youtube video</td>
-        </tr>
-    </table>
-
-    <br />
-    <div id="prolongNetworkIdleLoad">Waiting...</div>
-
-    <br />
-    <h1>Websocket Test</h1>
-    <img src="/balh.png"></img>
-
-    <!-- websockets.html -->
-    <input id="input" type="text" />
-    <button id="sendButton">Send</button>
-    <pre id="output"></pre>
-    <script type="module">
-        var input = document.getElementById("input");
-        var output = document.getElementById("output");
-        var prolongNetworkIdleLoadOutput = document.getElementById("prolongNetworkIdleLoad");
-        try {
-            const port = window.location.protocol;
-            const hs = window.location.hostname;
-            if (port == "http:") {
-                var socket = new WebSocket("ws://" + hs + ":80/ws/echo");
-            } else {
-                var socket = new WebSocket("wss://" + hs + ":443/ws/echo");
-            }
-        } catch (error) {
-            console.log(error);
-        }
-
-        var p2 = prolongNetworkIdleLoad();
-        p2.then(() => {
-            console.log('done p2');
-        })
-
-        socket.onopen = function () {
-            output.innerHTML += "Status: Connected\n";
-        };
-
-        socket.onmessage = function (e) {
-            output.innerHTML += "Server: " + e.data + "\n";
-        };
-
-        document.getElementById("sendButton").addEventListener("click", send, false);
-
-        function send() {
-            socket.send(input.value);
-            input.value = "";
-        }
-
-        async function prolongNetworkIdleLoad() {
-            for (var i = 0; i < 40; i++) {
-                await fetch('/ping')
-                    .then((data) => {
-                        console.log(data);
-                    }).catch(() => {
-                        console.log('some error');
-                    });
-            }
-
-            prolongNetworkIdleLoadOutput.innerText = "for loop complete";
-
-            return
-        }
-    </script>
-</body>
+	var links []indexLink
+	for _, rt := range app.routes() {
+		if rt.name != "" {
+			links = append(links, indexLink{ID: rt.name, Href: rt.pattern, Description: rt.description})
+		}
+	}
+	for _, page := range set.custom {
+		links = append(links, indexLink{
+			ID:          strings.ReplaceAll(page, "-", "_"),
+			Href:        "/" + page,
+			Description: set.description(page),
+		})
+	}
 
-</html>`)
+	app.render(w, "index", struct{ Links []indexLink }{links})
 }
 
+// embedYoutubeHandler serves a page embedding a video player. By default the
+// player is the local stand-in served by videoEmbedHandler from the virtual
+// host given by the "host" query parameter, so that the page works without
+// internet access. "external=true" embeds the real YouTube player instead.
 func (app *application) embedYoutubeHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-	<html>
-        <head>
-            <meta name="robots" content="noindex, nofollow" />
-        </head>
-        <body>
-            <div id="doneDiv"></div>
-            <iframe src="https://www.youtube.com/embed/gwO7k5RTE54?wmode=opaque&amp;enablejsapi=1" onload='document.getElementById("doneDiv").innerText = "Done!"'></iframe>
-        </body>
-    </html>`)
+	const videoID = "gwO7k5RTE54"
+
+	src := "https://www.youtube.com/embed/" + videoID + "?wmode=opaque&enablejsapi=1"
+	if external, _ := strconv.ParseBool(r.URL.Query().Get("external")); !external {
+		host := r.URL.Query().Get("host")
+		if host == "" {
+			host = defaultVideoEmbedHost
+		}
+		src = videoEmbedURL(r, host, videoID)
+	}
+
+	app.render(w, "embed-youtube", struct{ Src string }{src})
 }
 
 func (app *application) pingMainHtmlHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-	<html>
-        <head>
-            <title>Main page</title>
-            <meta name="robots" content="noindex, nofollow" />
-        </head>
-        <body>
-            <div id="frameType">main</div>
-            <div id="subFrameProlongNetworkIdleLoad">Waiting...</div>
-            <div id="subFrameServerMsg">Waiting...</div>
-            <a href="/ping-main-html" id="homeLink">home</a>
-            <br />
-            <iframe id="subFrame" src="/ping-html"></iframe>
-        </body>
-    </html>`)
+	app.render(w, "ping-main-html", nil)
 }
 
 func (app *application) pingHtmlHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-	<html>
-
-<head>
-    <meta name="robots" content="noindex, nofollow" />
-</head>
-
-<body>
-    <div id="prolongNetworkIdleLoad">Waiting...</div>
-    <div id="serverMsg">Waiting...</div>
-
-    <script>
-        var prolongNetworkIdleLoadOutput = document.getElementById("prolongNetworkIdleLoad");
-        var parentOutput = window.parent.document.getElementById('subFrameProlongNetworkIdleLoad');
-
-        var p = prolongNetworkIdleLoad();
-        p.then(() => {
-            prolongNetworkIdleLoadOutput.innerText += ' - for loop complete';
-            if (parentOutput) {
-                parentOutput.innerText = prolongNetworkIdleLoadOutput.innerText;
-            }
-        })
-
-        async function prolongNetworkIdleLoad() {
-            for (var i = 0; i < 10; i++) {
-                await fetch('/ping')
-                    .then(response => response.text())
-                    .then((data) => {
-                        prolongNetworkIdleLoadOutput.innerText = 'Waiting... ' + data;
-                        if (parentOutput) {
-                            parentOutput.innerText = prolongNetworkIdleLoadOutput.innerText;
-                        }
-                    });
-            }
-        }
-    </script>
-    <script src="/ping.js" async></script>
-</body>
-
-</html>`)
+	app.render(w, "ping-html", nil)
 }
 
 func (app *application) pingJSHandler(w http.ResponseWriter, r *http.Request) {
@@ -306,67 +276,60 @@ func (app *application) pingJSHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) textBoxHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-	<!DOCTYPE html>
-    <html>
-        <head>
-            <meta name="robots" content="noindex, nofollow" />
-        </head>
-        <body>
-            <form action="/dialogbox">
-                <input type="text" name="test"><br><br>
-                <button type="submit" id="nextBtn">Click Me!</button>
-            </form>
-        </body>
-    </html>`)
+	app.render(w, "textbox", nil)
 }
 
+// dialogBoxHandler serves a page which opens dialogs as configured by its
+// query parameters:
+//
+//   - dialogType: alert (default), confirm, prompt or beforeunload, or a comma
+//     separated list of them to open several dialogs one after the other.
+//   - message: the message of the dialog. Repeat it to set the message of
+//     each dialog in a sequence.
+//   - default: the default value of prompt dialogs, repeatable as message.
+//   - from: "timer" opens the dialogs from a timer, "iframe" from an iframe.
+//   - delay: the timer delay in milliseconds, 500 by default.
+//   - id: an opaque value sent back with the results.
+//
+// The value returned by each dialog is written to the page and posted to
+// /dialogbox/result.
 func (app *application) dialogBoxHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-	<!DOCTYPE html>
-    <html>
-        <head>
-            <meta name="robots" content="noindex, nofollow" />
-        </head>
-        <body>
-            <input type="button" value="home" onclick="myFunction()">
-            <div id='textField'>Hello World</div>
-
-            <script>
-                function myFunction() {
-                    window.location.href = '/';
-                }
-            </script>
-            <script>
-                const queryString = window.location.search;
-                const urlParams = new URLSearchParams(queryString);
-                const dialogType = urlParams.get('dialogType')
-                const div = document.getElementById('textField');
-
-                switch(dialogType) {
-                    case "confirm":
-                        confirm("Click accept");
-                        div.textContent = 'confirm dismissed';
-                        break;
-                    case "prompt":
-                        prompt("Add text and then click accept");
-                        div.textContent = 'prompt dismissed';
-                        break;
-                    case "beforeunload":
-                        window.addEventListener('beforeunload', (event) => {
-                            event.returnValue = "Are you sure you want to leave?";
-                            div.textContent = 'beforeunload dismissed';
-                        });
-                        break;
-                    case "alert":
-                    default:
-                        alert("Click accept");
-                        div.textContent = 'alert dismissed';
-                        break;
-                }
-            </script>
-        </body>
-    </html>`)
+	app.render(w, "dialogbox", nil)
+}
+
+// dialogResult is the value returned by a dialog opened on /dialogbox.
+type dialogResult struct {
+	Time    time.Time `json:"time"`
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+	Result  any       `json:"result"`
+	Frame   string    `json:"frame"`
+}
+
+// dialogBoxResultHandler records a dialogResult posted as JSON.
+func (app *application) dialogBoxResultHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var res dialogResult
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&res); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Time = time.Now()
+	app.dialogResults.add(res)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dialogBoxResultsHandler returns the recorded dialog results as JSON. A
+// DELETE request clears them.
+func (app *application) dialogBoxResultsHandler(w http.ResponseWriter, r *http.Request) {
+	serveRecords(w, r, &app.dialogResults)
 }
 
 func (app *application) robotstxt(w http.ResponseWriter, r *http.Request) {
@@ -391,28 +354,37 @@ func (app *application) wsEchoHandler(w http.ResponseWriter, r *http.Request) {
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("cannot update to ws connection", err)
+		slog.WarnContext(r.Context(), "cannot upgrade to ws connection", "err", err)
 		return
 	}
 
-	fmt.Printf("connection made with: %s\n", conn.RemoteAddr())
+	defer conn.Close()
+	defer app.webSockets.add(conn)()
+
+	slog.InfoContext(r.Context(), "ws connection made", "remote", conn.RemoteAddr().String())
+
+	app.metrics.wsConnections.WithLabelValues("/ws/echo").Inc()
+	defer app.metrics.wsConnections.WithLabelValues("/ws/echo").Dec()
 
 	for {
 		// Read message from browser
 		msgType, msg, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("cannot read ws message", err)
+			slog.InfoContext(r.Context(), "ws connection closed", "err", err)
 			return
 		}
 
-		// Print the message to the console
-		fmt.Printf("%s sent: %s\n", conn.RemoteAddr(), string(msg))
+		slog.DebugContext(r.Context(), "ws message received", "data", string(msg))
+		addWebSocketMessage(r.Context(), "send", msgType, msg)
+		app.metrics.wsMessages.WithLabelValues("/ws/echo", "in").Inc()
 
 		// Write message back to browser
 		if err = conn.WriteMessage(msgType, msg); err != nil {
-			log.Println("cannot write ws message", err)
+			slog.WarnContext(r.Context(), "cannot write ws message", "err", err)
 			return
 		}
+		addWebSocketMessage(r.Context(), "receive", msgType, msg)
+		app.metrics.wsMessages.WithLabelValues("/ws/echo", "out").Inc()
 	}
 }
 
@@ -449,259 +421,7 @@ func (app *application) otherHandler(w http.ResponseWriter, r *http.Request) {
 		MaxAge: 3600,
 	})
 
-	fmt.Fprintf(w, `
-	<!DOCTYPE html>
-<html>
-
-<head>
-    <title>Other page</title>
-    <meta name="robots" content="noindex, nofollow" />
-    <script>
-    function print(metric) {
-        console.log('name: ' + metric.name)
-        console.log('value: ' + metric.value)
-        console.log('rating: ' + metric.rating)
-        console.log('delta: ' + metric.delta)
-        console.log('num entries: ' + metric.entries.length)
-    }
-
-    async function load() {
-        let {
-            onCLS, onFID, onLCP, onFCP, onINP, onTTFB
-        } = await import('https://unpkg.com/web-vitals@3?module');
-
-        onCLS(print);
-        onFID(print);
-        onLCP(print);
-
-        onFCP(print);
-        onINP(print);
-        onTTFB(print);
-    }
-    load();
-    </script>
-    <script>
-        // window.alert("sometext");
-
-        function getCookies() {
-            const cDisplay = document.getElementById("cookies-demo");
-            cDisplay.textContent = "Cookies: " + document.cookie;
-        }
-
-        function getUserAgent() {
-            const uaDisplay = document.getElementById("useragent-demo");
-            uaDisplay.textContent = "Your UserAgent: " + navigator.userAgent;
-        }
-
-        function getTimezone() {
-            const tzDisplay = document.getElementById("timezone-demo");
-            tzDisplay.textContent = "Timezone: " + Intl.DateTimeFormat().resolvedOptions().timeZone;
-        }
-
-        function networkStatus() {
-            const nsDisplay = document.getElementById("network-demo");
-            nsDisplay.textContent = "Network Status: " + navigator.onLine;
-        }
-
-        function getGeolocation() {
-            navigator.geolocation.getCurrentPosition(function (position) {
-                let lat = position.coords.latitude;
-                let long = position.coords.longitude;
-
-                document.getElementById("geolocation-demo").innerHTML = "Lat: " + lat.toFixed(2) + " Long: " + long.toFixed(2) + "";
-            });
-        }
-
-        function handleCheckboxClick(cb) {
-            const cbDisplay = document.getElementById("checkbox-demo");
-            if (cb.checked) {
-                cbDisplay.textContent = "Thanks for checking the box"
-            } else {
-                cbDisplay.textContent = "You've just unchecked the box"
-            }
-        }
-
-        function handleInputText(it) {
-            const itDisplay = document.getElementById("text-demo");
-            if (it.value !== "") {
-                itDisplay.textContent = "Thanks for filling in the input text field"
-            } else {
-                itDisplay.textContent = "You've just removed everything from the input text field"
-            }
-        }
-
-        function inputTextOnFocus(it) {
-            const itDisplay = document.getElementById("text-demo");
-            itDisplay.textContent = "focused on input text field"
-        }
-
-        function inputTextOnFocusOut(it) {
-            const itDisplay = document.getElementById("text-demo");
-            itDisplay.textContent = "focused out off input text field"
-        }
-
-        function getLocale() {
-            const userLocale =
-                navigator.languages && navigator.languages.length
-                    ? navigator.languages[0]
-                    : navigator.language;
-
-            document.getElementById("locale-demo").innerHTML = userLocale;
-        }
-
-        var counter = 0;
-        function incrementCounter() {
-            const counterDisplay = document.getElementById("counter-demo");
-            console.log(counter)
-            counterDisplay.textContent = "Counter: " + ++counter;
-            console.log(counter);
-        }
-
-        function selectOnChange(sel) {
-            const sDisplay = document.getElementById("select-multiple-demo");
-            var opts = "Selected: ", opt;
-            var len = sel.options.length;
-            for (var i = 0; i < len; i++) {
-                opt = sel.options[i];
-
-                if (opt.selected) {
-                    opts = opts + opt.value + " ";
-                }
-            }
-            sDisplay.textContent = opts;
-        }
-
-        function attachDetach() {
-            const btn = document.getElementById("attach-detach-button");
-            if (btn.innerText == "Detach") {
-                document.getElementById("attach-detach").remove();
-                btn.innerText = "Attach";
-                return;
-            }
-
-            btn.innerText = "Detach";
-            let p = document.createElement("p");
-            p.id = "attach-detach";
-            p.innerText = "attached";
-            document.getElementById("attach-detach-cell").append(p)
-        }
-    </script>
-</head>
-
-<body onload="getLocale(); getTimezone(); getUserAgent(); networkStatus(); getCookies();">
-
-    <p><a href="/">&lt; Back</a></p>
-
-    <table>
-        <tr>
-            <td><button type="button" id="attach-detach-button" onclick="attachDetach()">Detach</button></td>
-            <td id="attach-detach-cell">
-                <p id="attach-detach">attached</p>
-            </td>
-        </tr>
-        <tr>
-            <td><button type="button" onclick="getGeolocation()">Get geolocation</button></td>
-            <td>
-                <p id="geolocation-demo">Lat: ? Long: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td>NA</td>
-            <td>
-                <p id="locale-demo">Locale: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td><button type="button" onclick="networkStatus()">Refresh network status</button></td>
-            <td>
-                <p id="network-demo">Network Status: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td>NA</td>
-            <td>
-                <p id="timezone-demo">Timezone: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td>NA</td>
-            <td>
-                <p id="useragent-demo">Your UserAgent: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td><button type="button" onclick="getCookies()">Refresh cookies</button></td>
-            <td>
-                <p id="cookies-demo">Cookies: ?</p>
-            </td>
-        </tr>
-        <tr>
-            <td>
-                <input type="checkbox" onclick="handleCheckboxClick(this);" id="checkbox1" name="checkbox1"
-                    value="Checkbox test 1">
-                <label for="checkbox1">Checkbox test 1</label><br>
-            </td>
-            <td>
-                <p id="checkbox-demo">No interaction</p>
-            </td>
-        </tr>
-        <tr>
-            <td><button type="button" id="counter-button" onclick="incrementCounter()">Increment</button></td>
-            <td>
-                <p id="counter-demo">Counter: 0</p>
-            </td>
-        </tr>
-        <tr>
-            <td><input type="text" oninput="handleInputText(this);" onfocus="inputTextOnFocus(this);" onfocusout="inputTextOnFocusOut(this);" id="text1"></td>
-            <td>
-                <p id="text-demo">No interaction</p>
-            </td>
-        </tr>
-        <tr>
-            <td><input type="text" disabled="true" id="input-text-disabled"></td>
-            <td>Disabled input text field</td>
-        </tr>
-        <tr>
-            <td><input type="text" hidden="true" id="input-text-hidden"></td>
-            <td>Hidden input text field</td>
-        </tr>
-        <tr>
-            <td><label for="numbers">Choose one or more numbers:</label></td>
-            <td>
-                <select name="numbers" id="numbers-options" onchange="selectOnChange(this)" multiple>
-                    <option value="zero">Zero</option>
-                    <option value="one">One</option>
-                    <option value="two">Two</option>
-                    <option value="three">Three</option>
-                    <option value="four">Four</option>
-                    <option value="five">Five</option>
-                </select>
-                <p id="select-multiple-demo">Nothing selected</p>
-            </td>
-        </tr>
-        <tr>
-            <td><label for="colors">Choose a color:</label></td>
-            <td>
-                <select name="colors" id="colors-options">
-                    <option value="none">None</option>
-                    <option value="red">Red</option>
-                    <option value="green">Green</option>
-                    <option value="blue">Blue</option>
-                    <option value="yellow">Yellow</option>
-                    <option value="black">Black</option>
-                    <option value="white">White</option>
-                </select>
-            </td>
-        </tr>
-    </table>
-
-    <div id="off-screen" style="position: absolute; top: 150vh; left: 100px;">
-        Off page div
-    </div>
-
-</body>
-
-</html>`)
+	app.render(w, "other", nil)
 }
 
 func (app *application) basicAuth(next http.HandlerFunc) http.HandlerFunc {
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics are the Prometheus metrics served at /metrics. Requests are
+// labelled with the pattern of the route that handled them rather than
+// their path, to keep the number of series bounded.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	requestBytes  *prometheus.CounterVec
+	responseBytes *prometheus.CounterVec
+	wsConnections *prometheus.GaugeVec
+	wsMessages    *prometheus.CounterVec
+}
+
+func newMetrics(app *application) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "testserver_http_requests_total",
+			Help: "Number of HTTP requests by route, method, status code and scheme.",
+		}, []string{"route", "method", "code", "scheme"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "testserver_http_request_duration_seconds",
+			Help:    "Time taken to handle HTTP requests by route and method.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"route", "method"}),
+		requestBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "testserver_http_request_bytes_total",
+			Help: "Request body bytes read by route.",
+		}, []string{"route"}),
+		responseBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "testserver_http_response_bytes_total",
+			Help: "Response body bytes written by route.",
+		}, []string{"route"}),
+		wsConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "testserver_websocket_connections",
+			Help: "Open WebSocket connections by endpoint.",
+		}, []string{"route"}),
+		wsMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "testserver_websocket_messages_total",
+			Help: "WebSocket messages by endpoint and direction (in or out of the server).",
+		}, []string{"route", "direction"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.requestBytes,
+		m.responseBytes,
+		m.wsConnections,
+		m.wsMessages,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "testserver_ping_counter",
+			Help: "Current value of the /ping counter.",
+		}, func() float64 {
+			app.counterMu.Lock()
+			defer app.counterMu.Unlock()
+			return float64(app.counter)
+		}),
+	)
+
+	return m
+}
+
+// instrument records the metrics of every request handled by mux.
+func (m *metrics) instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "none"
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+
+		start := time.Now()
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			m.requests.WithLabelValues(route, r.Method, strconv.Itoa(rw.status), scheme).Inc()
+			m.duration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+			m.requestBytes.WithLabelValues(route).Add(float64(body.n))
+			m.responseBytes.WithLabelValues(route).Add(float64(rw.n))
+		}()
+
+		mux.ServeHTTP(rw, r)
+	})
+}
+
+// metricsHandler serves the metrics in the Prometheus text format.
+func (app *application) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(app.metrics.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// responseRecorder records the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	n           int64
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(p)
+	rw.n += int64(n)
+	return n, err
+}
+
+func (rw *responseRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	conn, brw, err := hj.Hijack()
+	if err == nil && !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// defaultMockNamespace is used for mock routes created without a namespace.
+const defaultMockNamespace = "default"
+
+// mockResponse is a response sent by a mock route. Body is sent as is,
+// while Template is a text/template executed with a mockRequest.
+type mockResponse struct {
+	Status   int               `json:"status,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Template string            `json:"template,omitempty"`
+	Delay    string            `json:"delay,omitempty"`
+
+	tmpl  *template.Template
+	delay time.Duration
+}
+
+// mockRoute is a route created at runtime through the admin API. It is
+// served at /mock/<namespace><path> and matches requests by method, path
+// pattern, headers and query parameters. Successive calls get successive
+// Responses, the last one repeating once the sequence is exhausted unless
+// Cycle is set. A route without Responses sends its inline response.
+type mockRoute struct {
+	ID           string            `json:"id"`
+	Namespace    string            `json:"namespace"`
+	Method       string            `json:"method,omitempty"`
+	Path         string            `json:"path"`
+	MatchHeaders map[string]string `json:"matchHeaders,omitempty"`
+	MatchQuery   map[string]string `json:"matchQuery,omitempty"`
+	mockResponse
+	Responses []mockResponse `json:"responses,omitempty"`
+	Cycle     bool           `json:"cycle,omitempty"`
+	Calls     int            `json:"calls"`
+}
+
+// mockRequest is the data available to response templates.
+type mockRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+	Call   int
+}
+
+type mockStore struct {
+	mu     sync.Mutex
+	nextID int
+	routes []*mockRoute
+}
+
+func (s *mockStore) add(rt *mockRoute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	rt.ID = strconv.Itoa(s.nextID)
+	s.routes = append(s.routes, rt)
+}
+
+// list returns a copy of the routes of namespace, or of all of them if
+// namespace is empty.
+func (s *mockStore) list(namespace string) []mockRoute {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	routes := []mockRoute{}
+	for _, rt := range s.routes {
+		if namespace == "" || rt.Namespace == namespace {
+			routes = append(routes, *rt)
+		}
+	}
+	return routes
+}
+
+// remove deletes the routes for which match returns true and returns how many were deleted.
+func (s *mockStore) remove(match func(*mockRoute) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	routes := s.routes[:0]
+	for _, rt := range s.routes {
+		if !match(rt) {
+			routes = append(routes, rt)
+		}
+	}
+	n := len(s.routes) - len(routes)
+	s.routes = routes
+
+	return n
+}
+
+// match finds the most recently added route of namespace matching r, and
+// returns the response for this call along with its call number.
+func (s *mockStore) match(namespace, p string, r *http.Request) (*mockResponse, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.routes) - 1; i >= 0; i-- {
+		rt := s.routes[i]
+		if rt.Namespace != namespace || !rt.matches(p, r) {
+			continue
+		}
+
+		rt.Calls++
+		if len(rt.Responses) == 0 {
+			return &rt.mockResponse, rt.Calls, true
+		}
+		n := rt.Calls - 1
+		if rt.Cycle {
+			n %= len(rt.Responses)
+		} else if n >= len(rt.Responses) {
+			n = len(rt.Responses) - 1
+		}
+		return &rt.Responses[n], rt.Calls, true
+	}
+
+	return nil, 0, false
+}
+
+func (rt *mockRoute) matches(p string, r *http.Request) bool {
+	if rt.Method != "" && !strings.EqualFold(rt.Method, r.Method) {
+		return false
+	}
+	if !matchPath(rt.Path, p) {
+		return false
+	}
+	for k, v := range rt.MatchHeaders {
+		if !matchValue(v, r.Header.Values(k)) {
+			return false
+		}
+	}
+	q := r.URL.Query()
+	for k, v := range rt.MatchQuery {
+		if !matchValue(v, q[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPath matches p against pattern using path.Match, with a trailing
+// "/**" matching any number of path segments.
+func matchPath(pattern, p string) bool {
+	if strings.HasSuffix(pattern, "/**") {
+		prefix := strings.TrimSuffix(pattern, "/**")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+		pattern = prefix
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// matchValue reports whether one of values equals want. "*" only requires
+// values to be present.
+func matchValue(want string, values []string) bool {
+	for _, v := range values {
+		if want == "*" || v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (resp *mockResponse) compile() error {
+	if resp.Body != "" && resp.Template != "" {
+		return errors.New("body and template are mutually exclusive")
+	}
+	if resp.Status != 0 && (resp.Status < 100 || resp.Status > 999) {
+		return fmt.Errorf("invalid status %d", resp.Status)
+	}
+	if resp.Template != "" {
+		tmpl, err := template.New("response").Parse(resp.Template)
+		if err != nil {
+			return err
+		}
+		resp.tmpl = tmpl
+	}
+	if resp.Delay != "" {
+		delay, err := time.ParseDuration(resp.Delay)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("invalid delay %q", resp.Delay)
+		}
+		resp.delay = delay
+	}
+	return nil
+}
+
+func (rt *mockRoute) validate() error {
+	if rt.Namespace == "" {
+		rt.Namespace = defaultMockNamespace
+	}
+	if strings.Contains(rt.Namespace, "/") {
+		return fmt.Errorf("invalid namespace %q", rt.Namespace)
+	}
+	if !strings.HasPrefix(rt.Path, "/") {
+		return fmt.Errorf("path %q must start with /", rt.Path)
+	}
+	if _, err := path.Match(strings.TrimSuffix(rt.Path, "/**"), ""); err != nil {
+		return fmt.Errorf("invalid path %q: %w", rt.Path, err)
+	}
+	if err := rt.mockResponse.compile(); err != nil {
+		return err
+	}
+	for i := range rt.Responses {
+		if err := rt.Responses[i].compile(); err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// adminRoutesHandler is the admin API managing mock routes:
+//
+//   - POST /admin/routes creates the route given as JSON.
+//   - GET /admin/routes?namespace=ns lists the routes, of a namespace if
+//     given.
+//   - DELETE /admin/routes?namespace=ns deletes all the routes of a
+//     namespace.
+//   - GET and DELETE /admin/routes/<id> get and delete a single route.
+func (app *application) adminRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/routes"), "/")
+	namespace := r.URL.Query().Get("namespace")
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		var rt mockRoute
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rt.Calls = 0
+		if err := rt.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		app.mocks.add(&rt)
+		w.Header().Set("Location", "/admin/routes/"+rt.ID)
+		writeJSON(w, http.StatusCreated, app.findMock(rt.ID))
+	case id == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, app.mocks.list(namespace))
+	case id == "" && r.Method == http.MethodDelete:
+		if namespace == "" {
+			http.Error(w, "namespace is required", http.StatusBadRequest)
+			return
+		}
+		n := app.mocks.remove(func(rt *mockRoute) bool { return rt.Namespace == namespace })
+		writeJSON(w, http.StatusOK, map[string]int{"deleted": n})
+	case id != "" && r.Method == http.MethodGet:
+		rt := app.findMock(id)
+		if rt == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, rt)
+	case id != "" && r.Method == http.MethodDelete:
+		if app.mocks.remove(func(rt *mockRoute) bool { return rt.ID == id }) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (app *application) findMock(id string) *mockRoute {
+	for _, rt := range app.mocks.list("") {
+		if rt.ID == id {
+			return &rt
+		}
+	}
+	return nil
+}
+
+// mockHandler serves the mock routes at /mock/<namespace>/<path>.
+func (app *application) mockHandler(w http.ResponseWriter, r *http.Request) {
+	namespace, p, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/mock/"), "/")
+	p = "/" + p
+
+	resp, call, ok := app.mocks.match(namespace, p, r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no mock route for %s %s in namespace %q", r.Method, p, namespace), http.StatusNotFound)
+		return
+	}
+
+	body := []byte(resp.Body)
+	if resp.tmpl != nil {
+		reqBody, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var buf bytes.Buffer
+		err = resp.tmpl.Execute(&buf, mockRequest{
+			Method: r.Method,
+			Path:   p,
+			Query:  r.URL.Query(),
+			Header: r.Header,
+			Body:   string(reqBody),
+			Call:   call,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = buf.Bytes()
+	}
+
+	if resp.delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(resp.delay):
+		}
+	}
+
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("cannot encode response", "err", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// popupsHandler serves the pages opened by the popup fixtures:
+//
+//   - /popups/popup?label=x is the page opened in popups. close=500 makes it
+//     close itself after that many milliseconds, redirect=js makes it
+//     navigate to another popup page, and post=1 makes it post a message
+//     back to its opener.
+//   - /popups/redirect?label=x redirects to the popup page, so that popups
+//     start with a redirect.
+//   - /popups/frame is a frame opening popups from its own buttons.
+func (app *application) popupsHandler(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/popups/") {
+	case "popup":
+		app.render(w, "popups-popup", struct{ Label string }{r.URL.Query().Get("label")})
+	case "redirect":
+		q := r.URL.Query()
+		q.Set("redirected", "1")
+		http.Redirect(w, r, "/popups/popup?"+q.Encode(), http.StatusFound)
+	case "frame":
+		app.render(w, "popups-frame", nil)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// popupsIndexHandler serves a page with buttons opening popups with and
+// without noopener, popups which close right away or redirect, popups
+// opened from a frame, and a popup posting a message to its opener.
+func (app *application) popupsIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "popups", nil)
+}
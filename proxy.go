@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modes of the record and replay proxy.
+const (
+	proxyModeRecord = "record"
+	proxyModeReplay = "replay"
+)
+
+// maxRecordedBody is the largest request or response body recorded.
+const maxRecordedBody = 64 << 20
+
+// proxyConfig configures the record and replay proxy, which serves every
+// request made to host.
+type proxyConfig struct {
+	mode     string
+	upstream string
+	dir      string
+	host     string
+	// match lists the parts of a request identifying its recording:
+	// method, path, query and body.
+	match        string
+	replayTiming bool
+}
+
+// exchange is a recorded request and response, stored as one JSON file.
+type exchange struct {
+	Time       time.Time        `json:"time"`
+	DurationMS float64          `json:"durationMs"`
+	Request    recordedRequest  `json:"request"`
+	Response   recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// proxy records the exchanges with an upstream to disk, or replays them
+// offline.
+type proxy struct {
+	cfg      proxyConfig
+	upstream *url.URL
+	rp       *httputil.ReverseProxy
+
+	mu         sync.Mutex
+	seq        int
+	recordings map[string][]*exchange
+	calls      map[string]int
+}
+
+func newProxy(cfg proxyConfig) (*proxy, error) {
+	p := &proxy{
+		cfg:        cfg,
+		recordings: make(map[string][]*exchange),
+		calls:      make(map[string]int),
+	}
+	for _, part := range strings.Split(cfg.match, ",") {
+		switch part {
+		case "method", "path", "query", "body":
+		default:
+			return nil, fmt.Errorf("unknown match part %q", part)
+		}
+	}
+
+	switch cfg.mode {
+	case proxyModeRecord, "":
+		p.cfg.mode = proxyModeRecord
+		u, err := url.Parse(cfg.upstream)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream %q", cfg.upstream)
+		}
+		if err := os.MkdirAll(cfg.dir, 0o755); err != nil {
+			return nil, err
+		}
+		p.upstream = u
+		p.rp = &httputil.ReverseProxy{
+			Director:       p.direct,
+			ModifyResponse: p.modifyResponse,
+		}
+	case proxyModeReplay:
+		if err := p.load(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown proxy mode %q", cfg.mode)
+	}
+
+	return p, nil
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordedBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if p.cfg.mode == proxyModeReplay {
+		p.replay(w, r, body)
+		return
+	}
+	p.record(w, r, body)
+}
+
+func (p *proxy) direct(r *http.Request) {
+	r.URL.Scheme = p.upstream.Scheme
+	r.URL.Host = p.upstream.Host
+	r.URL.Path = singleJoiningSlash(p.upstream.Path, r.URL.Path)
+	r.URL.RawPath = ""
+	r.Host = p.upstream.Host
+	// Let the transport negotiate and decode compression, so that
+	// recordings hold decoded bodies.
+	r.Header.Del("Accept-Encoding")
+}
+
+// upstreamHeaderKey is the context key of the upstream response headers
+// of a request being recorded.
+type upstreamHeaderKey struct{}
+
+// modifyResponse makes redirects to the upstream stay on the proxy and
+// keeps the upstream headers for the recording, before the middleware of
+// the server adds its own.
+func (p *proxy) modifyResponse(resp *http.Response) error {
+	loc := resp.Header.Get("Location")
+	origin := p.upstream.Scheme + "://" + p.upstream.Host
+	if strings.HasPrefix(loc, origin) {
+		resp.Header.Set("Location", strings.TrimPrefix(loc, origin))
+	}
+	if h, ok := resp.Request.Context().Value(upstreamHeaderKey{}).(*http.Header); ok {
+		*h = resp.Header.Clone()
+	}
+	return nil
+}
+
+func singleJoiningSlash(a, b string) string {
+	switch {
+	case strings.HasSuffix(a, "/") && strings.HasPrefix(b, "/"):
+		return a + b[1:]
+	case !strings.HasSuffix(a, "/") && !strings.HasPrefix(b, "/"):
+		return a + "/" + b
+	}
+	return a + b
+}
+
+func (p *proxy) record(w http.ResponseWriter, r *http.Request, body []byte) {
+	ex := &exchange{
+		Time: time.Now(),
+		Request: recordedRequest{
+			Method: r.Method,
+			URL:    r.URL.RequestURI(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		},
+	}
+
+	// Responses of failed upstream requests have no upstream headers.
+	header := http.Header{}
+	ctx := context.WithValue(r.Context(), upstreamHeaderKey{}, &header)
+	rec := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	p.rp.ServeHTTP(rec, r.WithContext(ctx))
+
+	ex.DurationMS = float64(time.Since(ex.Time).Microseconds()) / 1000
+	ex.Response = recordedResponse{
+		Status: rec.status,
+		Header: header,
+		Body:   rec.body.Bytes(),
+	}
+	// Bodies are recorded decoded and replayed in full, and replayed
+	// responses get a fresh date.
+	ex.Response.Header.Del("Content-Encoding")
+	ex.Response.Header.Del("Content-Length")
+	ex.Response.Header.Del("Date")
+
+	if err := p.save(ex); err != nil {
+		slog.Error("cannot save recording", "err", err)
+	}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (p *proxy) save(ex *exchange) error {
+	p.mu.Lock()
+	p.seq++
+	seq := p.seq
+	p.mu.Unlock()
+
+	u, _ := url.Parse(ex.Request.URL)
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(u.Path, "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	file := filepath.Join(p.cfg.dir, fmt.Sprintf("%s-%06d-%s-%s.json", ex.Time.Format("20060102T150405"), seq, ex.Request.Method, name))
+
+	b, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0o644)
+}
+
+// load reads the recordings of the proxy directory, in the order they were
+// recorded.
+func (p *proxy) load() error {
+	files, err := filepath.Glob(filepath.Join(p.cfg.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no recordings in %s", p.cfg.dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var ex exchange
+		if err := json.Unmarshal(b, &ex); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		key, err := p.key(ex.Request.Method, ex.Request.URL, ex.Request.Body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		p.recordings[key] = append(p.recordings[key], &ex)
+	}
+	slog.Info("loaded recordings", "count", len(files), "dir", p.cfg.dir)
+
+	return nil
+}
+
+// key identifies a request by the parts listed in the match configuration.
+func (p *proxy) key(method, requestURI string, body []byte) (string, error) {
+	u, err := url.Parse(requestURI)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, part := range strings.Split(p.cfg.match, ",") {
+		switch part {
+		case "method":
+			parts = append(parts, method)
+		case "path":
+			parts = append(parts, u.Path)
+		case "query":
+			// Encode sorts the parameters by key.
+			parts = append(parts, u.Query().Encode())
+		case "body":
+			sum := sha256.Sum256(body)
+			parts = append(parts, hex.EncodeToString(sum[:]))
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// replay serves the recordings matching r in the order they were recorded,
+// repeating the last one once they are exhausted.
+func (p *proxy) replay(w http.ResponseWriter, r *http.Request, body []byte) {
+	key, err := p.key(r.Method, r.URL.RequestURI(), body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	recordings := p.recordings[key]
+	n := p.calls[key]
+	p.calls[key]++
+	p.mu.Unlock()
+
+	if len(recordings) == 0 {
+		http.Error(w, fmt.Sprintf("no recording for %s %s", r.Method, r.URL.RequestURI()), http.StatusNotFound)
+		return
+	}
+	if n >= len(recordings) {
+		n = len(recordings) - 1
+	}
+	ex := recordings[n]
+
+	if p.cfg.replayTiming {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Duration(ex.DurationMS * float64(time.Millisecond))):
+		}
+	}
+
+	// Older recordings hold the request ID of the recorded request.
+	for k, v := range ex.Response.Header {
+		if k != requestIDHeader {
+			w.Header()[k] = v
+		}
+	}
+	w.Header().Set("X-Replayed", "true")
+	w.WriteHeader(ex.Response.Status)
+	_, _ = w.Write(ex.Response.Body)
+}
+
+// recordingResponseWriter keeps a copy of the status and body written to
+// the client.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingResponseWriter) Write(p []byte) (int, error) {
+	if rw.body.Len() < maxRecordedBody {
+		rw.body.Write(p)
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recordingResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
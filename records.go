@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// maxRecords is the default number of entries a recordLog keeps in memory.
+// Older entries are dropped first.
+const maxRecords = 1000
+
+// recordLog is an in memory log of things the server observed, which tests
+// can read back through serveRecords. It keeps up to limit entries, or
+// maxRecords if limit is zero.
+type recordLog[T any] struct {
+	limit int
+
+	mu      sync.Mutex
+	entries []T
+}
+
+func (l *recordLog[T]) add(entries ...T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limit
+	if limit == 0 {
+		limit = maxRecords
+	}
+	l.entries = append(l.entries, entries...)
+	if len(l.entries) > limit {
+		l.entries = l.entries[len(l.entries)-limit:]
+	}
+}
+
+func (l *recordLog[T]) list() []T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]T{}, l.entries...)
+}
+
+func (l *recordLog[T]) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = nil
+}
+
+// serveRecords returns the entries of l as JSON. A DELETE request clears the
+// log.
+func serveRecords[T any](w http.ResponseWriter, r *http.Request, l *recordLog[T]) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(l.list()); err != nil {
+			slog.ErrorContext(r.Context(), "cannot encode records", "err", err)
+		}
+	case http.MethodDelete:
+		l.reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
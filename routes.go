@@ -0,0 +1,79 @@
+package main
+
+import "net/http"
+
+// route is an entry of the route registry. Routes with a name are linked
+// from the index page, using the name as the id of the links.
+type route struct {
+	pattern     string
+	handler     http.HandlerFunc
+	name        string
+	description string
+}
+
+// routes returns the route registry, in the order the named routes are
+// listed on the index page.
+func (app *application) routes() []route {
+	return []route{
+		{pattern: "/", handler: app.indexHandler},
+		{pattern: "/csp", handler: app.cspHandler, name: "csp", description: "Test CSP (look in console)"},
+		{pattern: "/other", handler: app.otherHandler, name: "other", description: "Go here for most tests"},
+		{pattern: "/protected", handler: app.basicAuth(app.protectedHandler), name: "protected", description: "Test for basic auth"},
+		{pattern: "/slow", handler: app.slowHandler, name: "slow", description: "You'll get a response back after 200ms"},
+		{pattern: "/dialogbox", handler: app.dialogBoxHandler, name: "dialogbox", description: "A page with a dialog box"},
+		{pattern: "/dialogbox/result", handler: app.dialogBoxResultHandler},
+		{pattern: "/dialogbox/results", handler: app.dialogBoxResultsHandler},
+		{pattern: "/embed-youtube", handler: app.embedYoutubeHandler, name: "embed_youtube", description: "A page with a embedded Youtube video (add ?external=true for the real one)"},
+		{pattern: "/video-embed/", handler: app.videoEmbedHandler},
+		{pattern: "/compression", handler: app.compressionHandler, name: "compression", description: "Compressed, mislabelled and corrupted response bodies"},
+		{pattern: "/compression/text", handler: app.compress(app.compressionTextHandler)},
+		{pattern: "/compression/mislabelled", handler: app.compressionMislabelledHandler},
+		{pattern: "/compression/corrupt", handler: app.compressionCorruptHandler},
+		{pattern: "/compression/log", handler: app.compressionLogHandler},
+		{pattern: "/upload", handler: app.uploadHandler, name: "upload", description: "File inputs with server side verification of uploads"},
+		{pattern: "/upload/submit", handler: app.uploadSubmitHandler},
+		{pattern: "/upload/received", handler: app.uploadReceivedHandler},
+		{pattern: "/download", handler: app.downloadHandler, name: "download", description: "Attachments, blob URLs, slow and failing downloads"},
+		{pattern: "/download/file", handler: app.downloadFileHandler},
+		{pattern: "/download/raw", handler: app.downloadRawHandler},
+		{pattern: "/download/slow", handler: app.downloadSlowHandler},
+		{pattern: "/download/fail", handler: app.downloadFailHandler},
+		{pattern: "/form", handler: app.formHandler, name: "form", description: "Every input type, submitted to a server side echo"},
+		{pattern: "/form/submit", handler: app.formSubmitHandler},
+		{pattern: "/web-vitals", handler: app.webVitalsIndexHandler, name: "web_vitals", description: "Pages producing known web vitals values"},
+		{pattern: "/web-vitals/", handler: app.webVitalsHandler},
+		{pattern: "/shadow-dom", handler: app.shadowDOMHandler, name: "shadow_dom", description: "Open, closed, nested and slotted shadow roots"},
+		{pattern: "/frames", handler: app.framesIndexHandler, name: "frames", description: "Nested, named, sandboxed and dynamic frame trees"},
+		{pattern: "/frames/", handler: app.framesHandler},
+		{pattern: "/popups", handler: app.popupsIndexHandler, name: "popups", description: "Popups and new windows opened with window.open"},
+		{pattern: "/popups/", handler: app.popupsHandler},
+		{pattern: "/input-events", handler: app.inputEventsHandler, name: "input_events", description: "Records every keyboard, mouse, pointer, wheel and touch event"},
+		{pattern: "/input-events/log", handler: app.inputEventsLogHandler},
+		{pattern: "/drag-and-drop", handler: app.dragAndDropHandler, name: "drag_and_drop", description: "HTML5 and pointer based drag and drop, and a file drop zone"},
+		{pattern: "/drag-and-drop/state", handler: app.dragAndDropStateHandler},
+		{pattern: "/actionability", handler: app.actionabilityHandler, name: "actionability", description: "Elements covered, animating, hidden, inert, disabled or detached"},
+		{pattern: "/scroll", handler: app.scrollIndexHandler, name: "scroll", description: "Scroll containers, sticky headers, infinite scroll and lazy images"},
+		{pattern: "/scroll/", handler: app.scrollHandler},
+		{pattern: "/stress", handler: app.stressIndexHandler, name: "stress", description: "Large DOMs, many frames and requests, CPU and memory load"},
+		{pattern: "/stress/", handler: app.stressHandler},
+		{pattern: "/sw/", handler: app.serviceWorkerHandler, name: "service_worker", description: "App with a service worker caching assets and serving an offline page"},
+		{pattern: "/workers", handler: app.workersHandler, name: "workers", description: "Dedicated, shared and module workers fetching, logging and throwing"},
+		{pattern: "/workers/data", handler: app.workersDataHandler},
+		{pattern: "/admin/routes", handler: app.adminRoutesHandler},
+		{pattern: "/admin/routes/", handler: app.adminRoutesHandler},
+		{pattern: "/mock/", handler: app.mockHandler},
+		{pattern: "/ws/echo", handler: app.wsEchoHandler},
+		{pattern: "/journal", handler: app.journalHandler},
+		{pattern: "/har", handler: app.harHandler},
+		{pattern: "/metrics", handler: app.metricsHandler},
+		{pattern: "/healthz", handler: app.healthzHandler},
+		{pattern: "/readyz", handler: app.readyzHandler},
+		{pattern: "/ping-main-html", handler: app.pingMainHtmlHandler},
+		{pattern: "/ping", handler: app.pingHandler},
+		{pattern: "/ping-html", handler: app.pingHtmlHandler},
+		{pattern: "/ping.js", handler: app.pingJSHandler},
+		{pattern: "/textbox", handler: app.textBoxHandler},
+		{pattern: "/assets/", handler: app.assetsHandler().ServeHTTP},
+		{pattern: "/robots.txt", handler: app.robotstxt},
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxScrollItems is the largest number of items served by the scroll
+// fixtures.
+const maxScrollItems = 10000
+
+// scrollItem is an item of the paginated API behind the infinite scroll
+// fixture.
+type scrollItem struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// scrollHandler serves scroll and viewport fixtures:
+//
+//   - /scroll/containers has horizontally, vertically and nested scroll
+//     containers, and targets covered by a sticky header when scrolled to
+//     the top of the viewport.
+//   - /scroll/infinite?total=200&size=20 appends pages of items from
+//     /scroll/items as the end of the list is scrolled into view.
+//   - /scroll/items?page=1&size=20&total=200&delay=0 returns a page of
+//     items as JSON, along with the number of the next page, or 0 after the
+//     last one.
+//   - /scroll/lazy?count=50 lists images with loading=lazy, served from
+//     /scroll/image.png after "delay".
+//
+// Each page marks the items currently in the viewport with a data-visible
+// attribute and lists the items which have been rendered or loaded.
+func (app *application) scrollHandler(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/scroll/") {
+	case "containers":
+		app.render(w, "scroll-containers", struct{ Items []int }{makeRange(100)})
+	case "infinite":
+		app.scrollInfiniteHandler(w, r)
+	case "items":
+		app.scrollItemsHandler(w, r)
+	case "lazy":
+		app.scrollLazyHandler(w, r)
+	case "image.png":
+		app.webVitalsImageHandler(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (app *application) scrollInfiniteHandler(w http.ResponseWriter, r *http.Request) {
+	total, err := parseSize(r, "total", 200)
+	if err != nil || total > maxScrollItems {
+		http.Error(w, "invalid total", http.StatusBadRequest)
+		return
+	}
+	size, err := parseSize(r, "size", 20)
+	if err != nil || size == 0 || size > 1000 {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+
+	delay, err := parseDuration(r, "delay", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "scroll-infinite", struct {
+		Total int64
+		Size  int64
+		Delay int64
+	}{total, size, delay.Milliseconds()})
+}
+
+func (app *application) scrollLazyHandler(w http.ResponseWriter, r *http.Request) {
+	count, err := parseSize(r, "count", 50)
+	if err != nil || count > maxScrollItems {
+		http.Error(w, "invalid count", http.StatusBadRequest)
+		return
+	}
+	delay, err := parseDuration(r, "delay", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "scroll-lazy", struct {
+		Images []int
+		Delay  int64
+	}{makeRange(int(count)), delay.Milliseconds()})
+}
+
+func (app *application) scrollItemsHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := parseSize(r, "page", 1)
+	if err != nil || page == 0 {
+		http.Error(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+	size, err := parseSize(r, "size", 20)
+	if err != nil || size == 0 || size > 1000 {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+	total, err := parseSize(r, "total", 200)
+	if err != nil || total > maxScrollItems {
+		http.Error(w, "invalid total", http.StatusBadRequest)
+		return
+	}
+	delay, err := parseDuration(r, "delay", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := struct {
+		Page  int64        `json:"page"`
+		Next  int64        `json:"next"`
+		Total int64        `json:"total"`
+		Items []scrollItem `json:"items"`
+	}{Page: page, Total: total, Items: []scrollItem{}}
+	for i := (page - 1) * size; i < page*size && i < total; i++ {
+		res.Items = append(res.Items, scrollItem{ID: int(i), Text: fmt.Sprintf("Item %d", i)})
+	}
+	if page*size < total {
+		res.Next = page + 1
+	}
+
+	time.Sleep(delay)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		slog.ErrorContext(r.Context(), "cannot encode items", "err", err)
+	}
+}
+
+func (app *application) scrollIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "scroll", nil)
+}
+
+// makeRange returns the integers from 0 to n-1, for templates to range over.
+func makeRange(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
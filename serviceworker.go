@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// serviceWorkerHandler serves an app controlled by a service worker:
+//
+//   - /sw/ registers /sw/worker.js, with the scope given by "scope" (/sw/
+//     by default). The worker script allows any scope with its
+//     Service-Worker-Allowed header, so that it can also control the whole
+//     origin.
+//   - /sw/offline is the page served by the worker when navigations fail,
+//     such as when the browser is offline.
+//   - /sw/style.css is served from the worker's cache once installed.
+//
+// The worker answers /ping itself, unless network=1 is added to it, and
+// relays push events and push-style messages posted by pages to every page
+// it controls.
+func (app *application) serviceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/sw/") {
+	case "":
+		app.render(w, "sw", nil)
+	case "offline":
+		app.render(w, "sw-offline", nil)
+	case "worker.js":
+		w.Header().Set("Service-Worker-Allowed", "/")
+		app.serveAsset(w, r, "assets/sw/worker.js", "text/javascript; charset=utf-8")
+	case "style.css":
+		app.serveAsset(w, r, "assets/sw/style.css", "text/css; charset=utf-8")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// serveAsset writes the embedded asset at name. Browsers check service
+// worker scripts for updates on every registration, so assets served this
+// way are never cached by HTTP.
+func (app *application) serveAsset(w http.ResponseWriter, r *http.Request, name, contentType string) {
+	b, err := assets.ReadFile(name)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,11 @@
+package main
+
+import "net/http"
+
+// shadowDOMHandler serves a page of web components for testing selectors
+// that pierce shadow roots: open and closed shadow roots, nested shadow
+// trees, slotted content, a declarative shadow root, and form associated
+// custom elements submitting to the form sink.
+func (app *application) shadowDOMHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "shadow-dom", nil)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSockets keeps track of the open WebSocket connections, which the HTTP
+// servers stop tracking once hijacked, so that they can be closed on
+// shutdown.
+type webSockets struct {
+	mu      sync.Mutex
+	conns   map[*websocket.Conn]struct{}
+	wg      sync.WaitGroup
+	closing bool
+}
+
+// goingAwayMessage is the close frame sent to clients on shutdown.
+var goingAwayMessage = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+
+// add tracks conn until the returned function is called, once the handler
+// is done with it. Connections added once shutdown has started are closed
+// straight away.
+func (ws *webSockets) add(conn *websocket.Conn) (done func()) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.closing {
+		_ = conn.WriteControl(websocket.CloseMessage, goingAwayMessage, time.Now().Add(time.Second))
+		_ = conn.Close()
+		return func() {}
+	}
+	if ws.conns == nil {
+		ws.conns = make(map[*websocket.Conn]struct{})
+	}
+	ws.conns[conn] = struct{}{}
+	ws.wg.Add(1)
+
+	return func() {
+		ws.mu.Lock()
+		delete(ws.conns, conn)
+		ws.mu.Unlock()
+		ws.wg.Done()
+	}
+}
+
+// goingAway sends a 1001 Going Away close frame on every connection and
+// waits for their handlers to return, which they do once the client
+// answers with its own close frame. Connections still open when ctx is done
+// are closed without waiting any longer.
+func (ws *webSockets) goingAway(ctx context.Context) {
+	deadline := time.Now().Add(time.Second)
+
+	ws.mu.Lock()
+	ws.closing = true
+	for conn := range ws.conns {
+		if err := conn.WriteControl(websocket.CloseMessage, goingAwayMessage, deadline); err != nil {
+			slog.Warn("cannot send ws close frame", "remote", conn.RemoteAddr().String(), "err", err)
+		}
+	}
+	ws.mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		ws.wg.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		ws.mu.Lock()
+		for conn := range ws.conns {
+			_ = conn.Close()
+		}
+		ws.mu.Unlock()
+	}
+}
+
+// shutdown stops the servers from accepting new connections and waits for
+// the requests in flight to be handled, for up to timeout. Requests still
+// running by then are cut off.
+func (app *application) shutdown(timeout time.Duration, servers ...*http.Server) {
+	app.ready.Store(false)
+	slog.Info("shutting down", "timeout", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Warn("cannot drain server", "addr", srv.Addr, "err", err)
+				_ = srv.Close()
+			}
+		}(srv)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.webSockets.goingAway(ctx)
+	}()
+	wg.Wait()
+
+	slog.Info("server stopped")
+}
+
+// healthzHandler reports that the process is up.
+func (app *application) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, "ok")
+}
+
+// readyzHandler reports whether both listeners are accepting connections,
+// so that scripts can wait for the server before starting browsers. It
+// fails again once the server starts shutting down.
+func (app *application) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if !app.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// isServerClosed reports whether err is the error returned by Serve once the
+// server is shut down.
+func isServerClosed(err error) bool {
+	return errors.Is(err, http.ErrServerClosed)
+}
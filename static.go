@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headersExt is the extension of the sidecar files describing how the file
+// of the same name (minus the extension) is served.
+const headersExt = ".headers"
+
+// staticMount is a local directory served at a URL prefix.
+type staticMount struct {
+	prefix string
+	dir    string
+}
+
+// staticMounts collects the -static flags, each given as prefix=dir.
+type staticMounts []staticMount
+
+func (m *staticMounts) String() string {
+	var s []string
+	for _, mount := range *m {
+		s = append(s, mount.prefix+"="+mount.dir)
+	}
+	return strings.Join(s, ",")
+}
+
+func (m *staticMounts) Set(v string) error {
+	prefix, dir, ok := strings.Cut(v, "=")
+	if !ok || prefix == "" || dir == "" {
+		return fmt.Errorf("want prefix=dir, got %q", v)
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	prefix = "/" + strings.Trim(prefix, "/") + "/"
+	if prefix == "//" {
+		prefix = "/"
+	}
+
+	// The mux panics on patterns registered twice.
+	for _, rt := range new(application).routes() {
+		if rt.pattern == prefix {
+			return fmt.Errorf("prefix %s is already used by a built in route", prefix)
+		}
+	}
+	for _, mount := range *m {
+		if mount.prefix == prefix {
+			return fmt.Errorf("prefix %s is already used by %s", prefix, mount.dir)
+		}
+	}
+
+	*m = append(*m, staticMount{prefix: prefix, dir: dir})
+
+	return nil
+}
+
+// fileOptions are read from the sidecar file of a static file. Each line of
+// a sidecar is a "Name: value" pair. Status and Delay are special and set the
+// response status code and a delay before responding, any other name is a
+// response header. Empty lines and lines starting with # are ignored.
+type fileOptions struct {
+	status int
+	delay  time.Duration
+	header http.Header
+}
+
+func parseFileOptions(b []byte) (*fileOptions, error) {
+	opts := &fileOptions{status: http.StatusOK, header: make(http.Header)}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing colon", n)
+		}
+		name, value = http.CanonicalHeaderKey(strings.TrimSpace(name)), strings.TrimSpace(value)
+
+		switch name {
+		case "Status":
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 100 || status > 999 {
+				return nil, fmt.Errorf("line %d: invalid status %q", n, value)
+			}
+			opts.status = status
+		case "Delay":
+			delay, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid delay %q", n, value)
+			}
+			opts.delay = delay
+		default:
+			opts.header.Add(name, value)
+		}
+	}
+
+	return opts, sc.Err()
+}
+
+// staticHandler serves the files of the directory of m. A file may have a
+// sidecar with the same name plus headersExt setting its status code,
+// headers and a delay. A sidecar without a file serves an empty body, which
+// is handy for redirects. Directories are served by their index.html.
+func (app *application) staticHandler(m staticMount) http.HandlerFunc {
+	fsys := os.DirFS(m.dir)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, m.prefix)), "/")
+		if name == "" {
+			name = "."
+		}
+		if strings.HasSuffix(name, headersExt) {
+			http.NotFound(w, r)
+			return
+		}
+		if fi, err := fs.Stat(fsys, name); err == nil && fi.IsDir() {
+			name = path.Join(name, "index.html")
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		fileMissing := errors.Is(err, fs.ErrNotExist)
+		if err != nil && !fileMissing {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		opts := &fileOptions{status: http.StatusOK, header: make(http.Header)}
+		sidecar, err := fs.ReadFile(fsys, name+headersExt)
+		switch {
+		case err == nil:
+			if opts, err = parseFileOptions(sidecar); err != nil {
+				http.Error(w, fmt.Sprintf("%s%s: %v", name, headersExt, err), http.StatusInternalServerError)
+				return
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		case fileMissing:
+			http.NotFound(w, r)
+			return
+		}
+
+		if opts.delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(opts.delay):
+			}
+		}
+
+		h := w.Header()
+		for k, v := range opts.header {
+			h[k] = v
+		}
+
+		if opts.status == http.StatusOK {
+			http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(body))
+			return
+		}
+		if h.Get("Content-Type") == "" && len(body) > 0 {
+			h.Set("Content-Type", http.DetectContentType(body))
+		}
+		w.WriteHeader(opts.status)
+		_, _ = w.Write(body)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Limits of the stress fixtures, high enough to hurt the browser without
+// letting a typo take the server down.
+const (
+	maxStressRows     = 1000000
+	maxStressCols     = 50
+	maxStressFrames   = 1000
+	maxStressRequests = 100000
+)
+
+// stressHandler serves pages for benchmarking the browser and the tool
+// driving it under load:
+//
+//   - /stress/dom?rows=100000&cols=5 streams a table of rows*cols cells.
+//   - /stress/frames?count=100 holds count iframes of /frames/leaf.
+//   - /stress/requests?count=1000&parallel=50&type=fetch makes count
+//     requests to /stress/resource, parallel at a time, with fetch, img or
+//     script.
+//   - /stress/cpu?duration=5000&chunk=50 keeps the main thread busy for
+//     duration milliseconds, in tasks of chunk milliseconds.
+//   - /stress/memory?mb=10&steps=10&interval=500 allocates mb megabytes
+//     every interval milliseconds, steps times, and keeps all of it.
+//
+// Pages report their progress in the DOM, and set data-done on the body
+// once finished.
+func (app *application) stressHandler(w http.ResponseWriter, r *http.Request) {
+	switch name := strings.TrimPrefix(r.URL.Path, "/stress/"); name {
+	case "dom":
+		app.stressDOMHandler(w, r)
+	case "frames":
+		count, err := parseSize(r, "count", 100)
+		if err != nil || count > maxStressFrames {
+			http.Error(w, "invalid count", http.StatusBadRequest)
+			return
+		}
+		app.render(w, "stress-frames", struct{ Frames []int }{makeRange(int(count))})
+	case "requests":
+		app.stressRequestsHandler(w, r)
+	case "resource":
+		app.stressResourceHandler(w, r)
+	case "cpu", "memory":
+		app.render(w, "stress-"+name, nil)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// stressDOMHandler streams its table instead of rendering it from a
+// template, so that huge pages are neither held in memory by the server nor
+// delayed until fully generated.
+func (app *application) stressDOMHandler(w http.ResponseWriter, r *http.Request) {
+	rows, err := parseSize(r, "rows", 10000)
+	if err != nil || rows > maxStressRows {
+		http.Error(w, "invalid rows", http.StatusBadRequest)
+		return
+	}
+	cols, err := parseSize(r, "cols", 5)
+	if err != nil || cols == 0 || cols > maxStressCols {
+		http.Error(w, "invalid cols", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	bw := bufio.NewWriterSize(w, 64<<10)
+	flush := func() {
+		_ = bw.Flush()
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	fmt.Fprintf(bw, `<!DOCTYPE html>
+<html>
+
+<head>
+    <title>Large DOM</title>
+    <meta name="robots" content="noindex, nofollow" />
+    <script>const start = performance.now();</script>
+</head>
+
+<body>
+<p><a href="/stress">&lt; Back</a></p>
+<p>Rows: <span id="rows">%d</span>, cells: <span id="cells">%d</span>, loaded in <span id="load-time"></span>ms</p>
+<table id="table">
+`, rows, rows*cols)
+	for i := int64(0); i < rows; i++ {
+		fmt.Fprintf(bw, "<tr id=\"row-%d\">", i)
+		for j := int64(0); j < cols; j++ {
+			fmt.Fprintf(bw, "<td>%d.%d</td>", i, j)
+		}
+		bw.WriteString("</tr>\n")
+		if i%1000 == 999 {
+			flush()
+			if r.Context().Err() != nil {
+				return
+			}
+		}
+	}
+	bw.WriteString(`</table>
+<script>
+    window.addEventListener('load', () => {
+        document.getElementById('load-time').textContent = Math.round(performance.now() - start);
+        document.body.dataset.done = true;
+    });
+</script>
+</body>
+
+</html>
+`)
+	flush()
+}
+
+func (app *application) stressRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	count, err := parseSize(r, "count", 1000)
+	if err != nil || count > maxStressRequests {
+		http.Error(w, "invalid count", http.StatusBadRequest)
+		return
+	}
+	parallel, err := parseSize(r, "parallel", 50)
+	if err != nil || parallel == 0 {
+		http.Error(w, "invalid parallel", http.StatusBadRequest)
+		return
+	}
+	typ := r.URL.Query().Get("type")
+	switch typ {
+	case "":
+		typ = "fetch"
+	case "fetch", "img", "script":
+	default:
+		http.Error(w, fmt.Sprintf("invalid type %q", typ), http.StatusBadRequest)
+		return
+	}
+	size, err := parseSize(r, "size", 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "stress-requests", struct {
+		Count    int64
+		Parallel int64
+		Type     string
+		Size     int64
+	}{count, parallel, typ, size})
+}
+
+// stressResourceHandler responds with size bytes of the content type asked
+// for by "type", or a tiny PNG for images. Responses are never cached, so that every
+// request reaches the server.
+func (app *application) stressResourceHandler(w http.ResponseWriter, r *http.Request) {
+	size, err := parseSize(r, "size", 100)
+	if err != nil || size > 10<<20 {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	switch r.URL.Query().Get("type") {
+	case "img":
+		b, err := solidPNG(1, 1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(b)
+	case "script":
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprintf(w, "/*%s*/\n", strings.Repeat("x", int(size)))
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		_, _ = w.Write([]byte(strings.Repeat("x", int(size))))
+	}
+}
+
+func (app *application) stressIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "stress", nil)
+}
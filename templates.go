@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// templateFS holds the embedded templates. layout.html is the layout shared
+// by every page, partials/ holds snippets used by several pages and each file
+// in pages/ is a page defining the "title", "head" and "body" templates used
+// by the layout. Pages may also define a "description" template, which is
+// shown next to the link to custom pages on the index.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// templates loads the page templates. Files in dir override the embedded
+// ones of the same name, and pages only found in dir are custom pages served
+// at /<name>. When dir is set the templates are read again on every request,
+// so that pages can be added or edited while the server is running.
+type templates struct {
+	dir string
+
+	mu  sync.Mutex
+	set *templateSet
+}
+
+type templateSet struct {
+	pages  map[string]*template.Template
+	custom []string
+}
+
+func (t *templates) get() (*templateSet, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.set != nil && t.dir == "" {
+		return t.set, nil
+	}
+
+	set, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	t.set = set
+
+	return set, nil
+}
+
+func (t *templates) load() (*templateSet, error) {
+	embedded, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	files, err := readTemplates(embedded)
+	if err != nil {
+		return nil, err
+	}
+	builtin := make(map[string]bool)
+	for name := range files {
+		builtin[name] = true
+	}
+
+	if t.dir != "" {
+		overrides, err := readTemplates(os.DirFS(t.dir))
+		if err != nil {
+			return nil, fmt.Errorf("reading templates from %s: %w", t.dir, err)
+		}
+		for name, b := range overrides {
+			files[name] = b
+		}
+	}
+
+	base, err := template.New("layout.html").Parse(string(files["layout.html"]))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(files) {
+		if !strings.HasPrefix(name, "partials/") {
+			continue
+		}
+		if _, err := base.New(name).Parse(string(files[name])); err != nil {
+			return nil, err
+		}
+	}
+
+	set := &templateSet{pages: make(map[string]*template.Template)}
+	for _, name := range sortedKeys(files) {
+		if !strings.HasPrefix(name, "pages/") {
+			continue
+		}
+		page := strings.TrimSuffix(path.Base(name), ".html")
+
+		tmpl, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(name).Parse(string(files[name])); err != nil {
+			return nil, err
+		}
+		set.pages[page] = tmpl
+
+		if !builtin[name] {
+			set.custom = append(set.custom, page)
+		}
+	}
+	sort.Strings(set.custom)
+
+	return set, nil
+}
+
+// readTemplates returns the contents of the .html files of fsys, keyed by
+// their path.
+func readTemplates(fsys fs.FS) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".html" {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files[name] = b
+		return nil
+	})
+	return files, err
+}
+
+// isCustomPage reports whether name is a page only found in the templates
+// directory.
+func (s *templateSet) isCustomPage(name string) bool {
+	i := sort.SearchStrings(s.custom, name)
+	return i < len(s.custom) && s.custom[i] == name
+}
+
+// description returns the output of the "description" template of a page,
+// or an empty string if it has none.
+func (s *templateSet) description(page string) string {
+	tmpl := s.pages[page]
+	if tmpl == nil || tmpl.Lookup("description") == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "description", nil); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// render writes the page with the given name, executed with data.
+func (app *application) render(w http.ResponseWriter, page string, data any) {
+	set, err := app.templates.get()
+	if err != nil {
+		slog.Error("cannot load templates", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, ok := set.pages[page]
+	if !ok {
+		slog.Error("no template for page", "page", page)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		slog.Error("cannot render page", "page", page, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	_, _ = buf.WriteTo(w)
+}
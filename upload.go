@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxUploadSize is the largest request body accepted by the upload endpoint.
+const maxUploadSize = 256 << 20
+
+// uploadedFile describes a file received by the upload endpoint. Only its
+// metadata and hash are kept, the contents are discarded.
+type uploadedFile struct {
+	Batch       int       `json:"batch"`
+	Time        time.Time `json:"time"`
+	Form        string    `json:"form"`
+	Field       string    `json:"field"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType"`
+	SHA256      string    `json:"sha256"`
+}
+
+type uploadStore struct {
+	mu      sync.Mutex
+	batches int
+	files   []uploadedFile
+}
+
+func (s *uploadStore) add(files []uploadedFile) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches++
+	for i := range files {
+		files[i].Batch = s.batches
+	}
+	s.files = append(s.files, files...)
+
+	return s.batches
+}
+
+func (s *uploadStore) list() []uploadedFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]uploadedFile{}, s.files...)
+}
+
+func (s *uploadStore) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files = nil
+}
+
+func (app *application) uploadHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "upload", nil)
+}
+
+// uploadSubmitHandler parses a multipart/form-data request and records the
+// name, size, MIME type and SHA-256 hash of every file in it. The "form" field
+// identifies which fixture the upload came from.
+func (app *application) uploadSubmitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		form  string
+		files []uploadedFile
+	)
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Plain fields and file inputs with nothing selected have no
+		// filename.
+		name := partFileName(part.Header.Get("Content-Disposition"))
+		if name == "" {
+			if part.FormName() == "form" {
+				b, _ := io.ReadAll(io.LimitReader(part, 1024))
+				form = string(b)
+			}
+			continue
+		}
+
+		h := sha256.New()
+		n, err := io.Copy(h, part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		files = append(files, uploadedFile{
+			Time:        time.Now(),
+			Field:       part.FormName(),
+			Name:        name,
+			Size:        n,
+			ContentType: part.Header.Get("Content-Type"),
+			SHA256:      hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+
+	for i := range files {
+		files[i].Form = form
+	}
+	batch := app.uploads.add(files)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"batch": batch, "files": files}); err != nil {
+			slog.ErrorContext(r.Context(), "cannot encode uploaded files", "err", err)
+		}
+		return
+	}
+
+	app.render(w, "upload-result", struct {
+		Batch int
+		Files []uploadedFile
+	}{batch, files})
+}
+
+// uploadReceivedHandler returns the metadata of every received file as JSON.
+// A DELETE request forgets all of them.
+func (app *application) uploadReceivedHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(app.uploads.list()); err != nil {
+			slog.ErrorContext(r.Context(), "cannot encode uploaded files", "err", err)
+		}
+	case http.MethodDelete:
+		app.uploads.reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// partFileName returns the unmodified filename of a multipart part.
+// mime/multipart strips any directory from it, which would lose the
+// relative paths sent for directory uploads.
+func partFileName(contentDisposition string) string {
+	_, params, err := mime.ParseMediaType(contentDisposition)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
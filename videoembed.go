@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultVideoEmbedHost is the virtual host serving the local stand-in for
+// the YouTube embed. Browsers resolve *.localhost to the loopback address,
+// so it reaches this server from a different origin than localhost.
+const defaultVideoEmbedHost = "video.localhost"
+
+// videoEmbedURL returns the URL of the local video embed with the given id,
+// on host and on the same scheme and port as r.
+func videoEmbedURL(r *http.Request, host, id string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if _, port, err := net.SplitHostPort(r.Host); err == nil {
+		host = net.JoinHostPort(host, port)
+	}
+	return fmt.Sprintf("%s://%s/video-embed/embed/%s?wmode=opaque&enablejsapi=1", scheme, host, id)
+}
+
+// videoEmbedHandler serves a stand-in for a heavy third party video embed:
+//
+//   - /video-embed/embed/{id}?frames=2&resources=30&busy=200 is the player
+//     page, with nested frames, a media element, many subresources and
+//     scripts keeping the main thread busy for busy milliseconds.
+//   - /video-embed/frame?depth=n is a frame nesting n more frames.
+//   - /video-embed/player.js is the long running player script.
+//   - /video-embed/resource/{n}.{js,css,png} are the subresources.
+//   - /video-embed/media.wav?seconds=5 is the media played by the player.
+func (app *application) videoEmbedHandler(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/video-embed/")
+	switch {
+	case strings.HasPrefix(p, "embed/"):
+		app.videoEmbedPlayerHandler(w, r, strings.TrimPrefix(p, "embed/"))
+	case p == "frame":
+		app.videoEmbedFrameHandler(w, r)
+	case p == "player.js":
+		app.videoEmbedPlayerJSHandler(w, r)
+	case strings.HasPrefix(p, "resource/"):
+		app.videoEmbedResourceHandler(w, r, strings.TrimPrefix(p, "resource/"))
+	case p == "media.wav":
+		app.videoEmbedMediaHandler(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (app *application) videoEmbedPlayerHandler(w http.ResponseWriter, r *http.Request, id string) {
+	frames, err := parseSize(r, "frames", 2)
+	if err != nil || frames > 10 {
+		http.Error(w, "invalid frames", http.StatusBadRequest)
+		return
+	}
+	resources, err := parseSize(r, "resources", 30)
+	if err != nil || resources > 1000 {
+		http.Error(w, "invalid resources", http.StatusBadRequest)
+		return
+	}
+	busy, err := parseSize(r, "busy", 200)
+	if err != nil {
+		http.Error(w, "invalid busy", http.StatusBadRequest)
+		return
+	}
+
+	type resource struct {
+		Kind string
+		URL  string
+	}
+	resourceKinds := []string{"js", "css", "png"}
+	var subresources []resource
+	for i := int64(0); i < resources; i++ {
+		kind := resourceKinds[i%3]
+		subresources = append(subresources, resource{Kind: kind, URL: fmt.Sprintf("/video-embed/resource/%d.%s", i, kind)})
+	}
+
+	app.render(w, "video-embed", struct {
+		ID        string
+		Frames    int64
+		Resources []resource
+		Busy      int64
+	}{id, frames, subresources, busy})
+}
+
+func (app *application) videoEmbedFrameHandler(w http.ResponseWriter, r *http.Request) {
+	depth, err := parseSize(r, "depth", 0)
+	if err != nil || depth > 10 {
+		http.Error(w, "invalid depth", http.StatusBadRequest)
+		return
+	}
+
+	app.render(w, "video-embed-frame", struct {
+		Depth int64
+		Next  int64
+	}{depth, depth - 1})
+}
+
+func (app *application) videoEmbedPlayerJSHandler(w http.ResponseWriter, r *http.Request) {
+	busy, err := parseSize(r, "busy", 200)
+	if err != nil {
+		http.Error(w, "invalid busy", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	fmt.Fprintf(w, `
+        const busy = %d;
+
+        function work(ms) {
+            const end = performance.now() + ms;
+            let x = 0;
+            while (performance.now() < end) {
+                x += Math.sqrt(x + 1);
+            }
+            return x;
+        }
+
+        // Keep the main thread busy while the player initialises, and then
+        // keep doing some work periodically like a real player would.
+        work(busy);
+        setInterval(() => work(busy / 10), 1000);
+
+        const player = document.getElementById('player');
+        const state = document.getElementById('state');
+        player.addEventListener('canplay', () => { state.textContent = 'ready'; });
+        player.addEventListener('playing', () => { state.textContent = 'playing'; });
+        player.addEventListener('error', () => { state.textContent = 'error'; });
+        player.play().catch(() => { state.textContent = 'paused'; });
+	`, busy)
+}
+
+func (app *application) videoEmbedResourceHandler(w http.ResponseWriter, r *http.Request, name string) {
+	base, ext, _ := strings.Cut(name, ".")
+	n, err := strconv.Atoi(base)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// Spread the subresources over a few hundred milliseconds.
+	time.Sleep(time.Duration(n%10) * 20 * time.Millisecond)
+
+	h := w.Header()
+	h.Set("Cache-Control", "no-store")
+	switch ext {
+	case "js":
+		h.Set("Content-Type", "text/javascript; charset=utf-8")
+		fmt.Fprintf(w, "window.videoEmbedResources = (window.videoEmbedResources || 0) + 1; // %d\n", n)
+	case "css":
+		h.Set("Content-Type", "text/css; charset=utf-8")
+		fmt.Fprintf(w, ".resource-%d { color: inherit; }\n", n)
+	case "png":
+		b, err := solidPNG(1, 1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.Set("Content-Type", "image/png")
+		_, _ = w.Write(b)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// videoEmbedMediaHandler serves a WAV file of a sine tone lasting the given
+// number of seconds, with support for range requests.
+func (app *application) videoEmbedMediaHandler(w http.ResponseWriter, r *http.Request) {
+	seconds, err := parseSize(r, "seconds", 5)
+	if err != nil || seconds == 0 || seconds > 600 {
+		http.Error(w, "invalid seconds", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/wav")
+	http.ServeContent(w, r, "media.wav", time.Time{}, bytes.NewReader(sineWAV(int(seconds))))
+}
+
+// sineWAV returns a mono, 8 kHz, 16 bit PCM WAV file of a 440 Hz tone.
+func sineWAV(seconds int) []byte {
+	const rate = 8000
+
+	samples := rate * seconds
+	var buf bytes.Buffer
+	buf.Grow(44 + samples*2)
+
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+samples*2))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16),       // fmt chunk size
+		uint16(1),        // PCM
+		uint16(1),        // channels
+		uint32(rate),     // sample rate
+		uint32(rate * 2), // byte rate
+		uint16(2),        // block align
+		uint16(16),       // bits per sample
+	} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(samples*2))
+	for i := 0; i < samples; i++ {
+		v := int16(math.Sin(2*math.Pi*440*float64(i)/rate) * math.MaxInt16 / 4)
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webVitalsFixtures are the web vitals with a page producing a known value.
+// The pages are the "web-vitals-<name>" templates.
+var webVitalsFixtures = map[string]bool{
+	"cls":  true,
+	"lcp":  true,
+	"fcp":  true,
+	"inp":  true,
+	"ttfb": true,
+}
+
+// webVitalsHandler serves pages which produce known web vitals values:
+//
+//   - /web-vitals/cls?distance=0.25&delay=500 shifts a block down by a
+//     fraction of the viewport height after a delay.
+//   - /web-vitals/lcp?delay=1000 loads a large image which takes delay
+//     milliseconds to be served.
+//   - /web-vitals/fcp?delay=1000 renders its first content after a delay.
+//   - /web-vitals/inp?block=300 blocks the main thread for block
+//     milliseconds when its button is clicked.
+//   - /web-vitals/ttfb?delay=500 waits before sending the response.
+//
+// Each page reports the measured values, using the locally served
+// web-vitals module, along with the expected one.
+func (app *application) webVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/web-vitals/")
+	if name == "image.png" {
+		app.webVitalsImageHandler(w, r)
+		return
+	}
+
+	if !webVitalsFixtures[name] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if name == "ttfb" {
+		delay, err := parseDuration(r, "delay", 500*time.Millisecond)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		time.Sleep(delay)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	app.render(w, "web-vitals-"+name, nil)
+}
+
+// webVitalsImageHandler serves a solid PNG image of the given width and
+// height after the given delay.
+func (app *application) webVitalsImageHandler(w http.ResponseWriter, r *http.Request) {
+	width, err := parseSize(r, "width", 1000)
+	if err != nil || width == 0 || width > 4096 {
+		http.Error(w, "invalid width", http.StatusBadRequest)
+		return
+	}
+	height, err := parseSize(r, "height", 800)
+	if err != nil || height == 0 || height > 4096 {
+		http.Error(w, "invalid height", http.StatusBadRequest)
+		return
+	}
+	delay, err := parseDuration(r, "delay", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := solidPNG(int(width), int(height))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	time.Sleep(delay)
+
+	h := w.Header()
+	h.Set("Content-Type", "image/png")
+	h.Set("Content-Length", strconv.Itoa(len(b)))
+	h.Set("Cache-Control", "no-store")
+	_, _ = w.Write(b)
+}
+
+// solidPNG encodes a single colour PNG image of the given size.
+func solidPNG(width, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}}, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (app *application) webVitalsIndexHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "web-vitals", nil)
+}
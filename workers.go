@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// workersHandler serves a page starting a dedicated worker, a shared worker
+// and a module worker, each of which fetches /workers/data as soon as it
+// starts. Buttons make each worker fetch again, log to the console, throw
+// an uncaught error or echo a message back. The workers' scripts are
+// served from /assets/workers/.
+func (app *application) workersHandler(w http.ResponseWriter, r *http.Request) {
+	app.render(w, "workers", nil)
+}
+
+// workersDataHandler responds to the fetches made by workers with the kind
+// of worker named by "from".
+func (app *application) workersDataHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	res := map[string]any{"from": r.URL.Query().Get("from"), "time": time.Now()}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		slog.ErrorContext(r.Context(), "cannot encode worker data", "err", err)
+	}
+}